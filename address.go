@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// AddressFormatEIP55 for Ethereum addresses with an optional mixed-case checksum
+const AddressFormatEIP55 = "eip55"
+
+// AddressFormatBech32 for addresses encoded with bech32 (BIP-173)
+const AddressFormatBech32 = "bech32"
+
+// AddressFormatBech32m for addresses encoded with bech32m (BIP-350)
+const AddressFormatBech32m = "bech32m"
+
+// bech32Charset to store characters used by bech32 and bech32m encodings
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Constants to store the checksum constant of each bech32 variant
+var bech32Constants = map[string]uint32{
+	AddressFormatBech32:  1,
+	AddressFormatBech32m: 0x2bc830a3,
+}
+
+// ValidateAddress verifies the address with the given format
+// The human readable part of bech32 addresses must be the expected prefix (ex: "xch")
+func ValidateAddress(format string, prefix string, address string) error {
+	switch format {
+	case "":
+		return nil
+	case AddressFormatEIP55:
+		return validateEIP55(address)
+	case AddressFormatBech32, AddressFormatBech32m:
+		hrp, err := decodeBech32(format, address)
+		if err != nil {
+			return err
+		}
+		if hrp != prefix {
+			return fmt.Errorf("Address prefix must be %q, not %q", prefix, hrp)
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unknown address format %q (available: %s, %s, %s)", format, AddressFormatEIP55, AddressFormatBech32, AddressFormatBech32m)
+	}
+}
+
+// validateEIP55 verifies an hexadecimal address and its checksum when it is written in mixed case
+func validateEIP55(address string) error {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return fmt.Errorf("Address must start with 0x followed by 40 hexadecimal characters")
+	}
+	digits := address[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return fmt.Errorf("Address is not hexadecimal")
+	}
+
+	// Addresses in a single case do not carry a checksum
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(strings.ToLower(digits)))
+	sum := hash.Sum(nil)
+	for i, c := range digits {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+		upper := c >= 'A' && c <= 'F'
+		if upper != (nibble >= 8) {
+			return fmt.Errorf("Invalid EIP-55 checksum")
+		}
+	}
+	return nil
+}
+
+// decodeBech32 verifies a bech32 or bech32m string and returns its human readable part
+func decodeBech32(format string, address string) (string, error) {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return "", fmt.Errorf("Address must not be in mixed case")
+	}
+	address = strings.ToLower(address)
+
+	separator := strings.LastIndex(address, "1")
+	if separator < 1 || separator+7 > len(address) || len(address) > 90 {
+		return "", fmt.Errorf("Invalid %s address length or separator", format)
+	}
+	hrp := address[:separator]
+
+	var values []byte
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return "", fmt.Errorf("Invalid character in %s address prefix", format)
+		}
+	}
+	for _, c := range hrp {
+		values = append(values, byte(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c)&31)
+	}
+	for _, c := range address[separator+1:] {
+		value := strings.IndexRune(bech32Charset, c)
+		if value == -1 {
+			return "", fmt.Errorf("Invalid character %q in %s address", c, format)
+		}
+		values = append(values, byte(value))
+	}
+
+	if bech32Polymod(values) != bech32Constants[format] {
+		return "", fmt.Errorf("Invalid %s checksum", format)
+	}
+	return hrp, nil
+}
+
+// bech32Polymod computes the bech32 checksum of values
+func bech32Polymod(values []byte) uint32 {
+	generator := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
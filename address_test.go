@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEIP55(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		valid   bool
+	}{
+		// Vectors from the EIP-55 specification
+		{"mixed case", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"mixed case", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", true},
+		{"mixed case", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", true},
+		{"mixed case", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", true},
+		{"all caps", "0x52908400098527886E0F7030069857D2E4169EE7", true},
+		{"all caps", "0x8617E340B3D01FA5F11F306F4090FD50E238070D", true},
+		{"all lower", "0xde709f2102306220921060314715629080e2fb77", true},
+		{"all lower", "0x27b1fdb04752bbc536007a920d24acb045561c26", true},
+		// Invalid addresses
+		{"bad checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"bad checksum", "0x5AaEB6053f3e94c9B9a09F33669435e7eF1bEaED", false},
+		{"missing prefix", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", false},
+		{"too short", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", false},
+		{"too long", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", false},
+		{"not hexadecimal", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name+" "+tc.address, func(t *testing.T) {
+			err := ValidateAddress(AddressFormatEIP55, "", tc.address)
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid address, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid address")
+			}
+		})
+	}
+}
+
+func TestDecodeBech32(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		address string
+		hrp     string
+		valid   bool
+	}{
+		// Valid vectors from BIP-173
+		{"bech32", AddressFormatBech32, "A12UEL5L", "a", true},
+		{"bech32", AddressFormatBech32, "a12uel5l", "a", true},
+		{"bech32", AddressFormatBech32, "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", "abcdef", true},
+		{"bech32", AddressFormatBech32, "11" + strings.Repeat("q", 82) + "c8247j", "1", true},
+		{"bech32", AddressFormatBech32, "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", "split", true},
+		{"bech32", AddressFormatBech32, "?1ezyfcl", "?", true},
+		// Valid vectors from BIP-350
+		{"bech32m", AddressFormatBech32m, "A1LQFN3A", "a", true},
+		{"bech32m", AddressFormatBech32m, "a1lqfn3a", "a", true},
+		{"bech32m", AddressFormatBech32m, "an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", "an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber1", true},
+		{"bech32m", AddressFormatBech32m, "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", "abcdef", true},
+		{"bech32m", AddressFormatBech32m, "split1checkupstagehandshakeupstreamerranterredcaperredlc445v", "split", true},
+		{"bech32m", AddressFormatBech32m, "?1v759aa", "?", true},
+		// Invalid vectors from BIP-350
+		{"no separator", AddressFormatBech32m, "qyrz8wqd2c9m", "", false},
+		{"empty hrp", AddressFormatBech32m, "1qyrz8wqd2c9m", "", false},
+		{"invalid data character", AddressFormatBech32m, "y1b0jsk6g", "", false},
+		{"invalid data character", AddressFormatBech32m, "lt1igcx5c0", "", false},
+		{"too short checksum", AddressFormatBech32m, "in1muywd", "", false},
+		{"invalid checksum character", AddressFormatBech32m, "mm1crxm3i", "", false},
+		{"invalid checksum character", AddressFormatBech32m, "au1s5cgom", "", false},
+		{"checksum of upper case hrp", AddressFormatBech32m, "M1VUXWEZ", "", false},
+		{"empty hrp", AddressFormatBech32m, "16plkw9", "", false},
+		{"empty hrp", AddressFormatBech32m, "1p2gdwpf", "", false},
+		// Checksums are specific to each variant
+		{"bech32 as bech32m", AddressFormatBech32m, "a12uel5l", "", false},
+		{"bech32m as bech32", AddressFormatBech32, "a1lqfn3a", "", false},
+		// Mixed case
+		{"mixed case", AddressFormatBech32, "A12uEL5L", "", false},
+		{"mixed case", AddressFormatBech32m, "abcdef1L7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", "", false},
+		// Bad checksum
+		{"bad checksum", AddressFormatBech32m, "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryy", "", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name+" "+tc.address, func(t *testing.T) {
+			hrp, err := decodeBech32(tc.format, tc.address)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("Expected invalid address, got prefix %q", hrp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected valid address, got %v", err)
+			}
+			if hrp != tc.hrp {
+				t.Errorf("Expected prefix %q, got %q", tc.hrp, hrp)
+			}
+		})
+	}
+}
+
+func TestValidateAddressPrefix(t *testing.T) {
+	if err := ValidateAddress(AddressFormatBech32m, "abcdef", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"); err != nil {
+		t.Errorf("Expected valid address, got %v", err)
+	}
+	if err := ValidateAddress(AddressFormatBech32m, "xch", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"); err == nil {
+		t.Error("Expected wrong prefix to be rejected")
+	}
+	if err := ValidateAddress(AddressFormatBech32, "a", "A12UEL5L"); err != nil {
+		t.Errorf("Expected upper case address to match a lower case prefix, got %v", err)
+	}
+	if err := ValidateAddress("", "", "anything"); err != nil {
+		t.Errorf("Expected address without format to be valid, got %v", err)
+	}
+	if err := ValidateAddress("unknown", "", "anything"); err == nil {
+		t.Error("Expected unknown format to be rejected")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// AmountPrecision to store the precision in bits used to convert amounts to coin units
+const AmountPrecision = 256
+
+// Amount to store an exact quantity in the smallest unit of a coin (ex: Weis for ETH)
+// Amounts are immutable, operations return new amounts
+type Amount struct {
+	value *big.Int
+}
+
+// NewAmount creates an Amount from an integer
+func NewAmount(value int64) Amount {
+	return Amount{value: big.NewInt(value)}
+}
+
+// NewAmountFromInt creates an Amount from a copy of a big integer
+func NewAmountFromInt(value *big.Int) Amount {
+	return Amount{value: new(big.Int).Set(value)}
+}
+
+// ParseAmount creates an Amount from its decimal representation
+// Exponents and decimals are accepted (ex: "1.5e+18") and truncated to an integer
+func ParseAmount(text string) (Amount, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return NewAmount(0), nil
+	}
+	if value, ok := new(big.Int).SetString(text, 10); ok {
+		return Amount{value: value}, nil
+	}
+	rat, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Amount{}, fmt.Errorf("Invalid amount %q", text)
+	}
+	return Amount{value: new(big.Int).Quo(rat.Num(), rat.Denom())}, nil
+}
+
+// ParseUnits creates an Amount from a value expressed in units of a coin with the given decimals
+// Example: 0.05 ETH with 18 decimals is 50000000000000000 Weis
+func ParseUnits(value float64, decimals int) Amount {
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	rat.Mul(rat, new(big.Rat).SetInt(pow10(decimals)))
+	return Amount{value: new(big.Int).Quo(rat.Num(), rat.Denom())}
+}
+
+// pow10 returns 10 to the power of n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Int returns a copy of the amount as a big integer
+func (a Amount) Int() *big.Int {
+	if a.value == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.value)
+}
+
+// Add returns the sum of two amounts
+func (a Amount) Add(b Amount) Amount {
+	return Amount{value: new(big.Int).Add(a.Int(), b.Int())}
+}
+
+// Sub returns the difference of two amounts
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{value: new(big.Int).Sub(a.Int(), b.Int())}
+}
+
+// Mul returns the amount multiplied by an integer
+func (a Amount) Mul(n int64) Amount {
+	return Amount{value: new(big.Int).Mul(a.Int(), big.NewInt(n))}
+}
+
+// Div returns the amount divided by an integer, truncated toward zero
+func (a Amount) Div(n int64) Amount {
+	return Amount{value: new(big.Int).Quo(a.Int(), big.NewInt(n))}
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (a Amount) Cmp(b Amount) int {
+	return a.Int().Cmp(b.Int())
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount
+func (a Amount) Sign() int {
+	if a.value == nil {
+		return 0
+	}
+	return a.value.Sign()
+}
+
+// IsZero returns true when the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Float64 returns the nearest float64 value of the amount, for estimations only
+func (a Amount) Float64() float64 {
+	value, _ := new(big.Float).SetInt(a.Int()).Float64()
+	return value
+}
+
+// Convert divides the amount by 10 to the power of decimals
+func (a Amount) Convert(decimals int) *big.Float {
+	value := new(big.Float).SetPrec(AmountPrecision).SetInt(a.Int())
+	divider := new(big.Float).SetPrec(AmountPrecision).SetInt(pow10(decimals))
+	return value.Quo(value, divider)
+}
+
+// FormatUnits returns the exact decimal representation of the amount divided by 10 to the power of decimals
+// Example: 50000000000000000 with 18 decimals is "0.05"
+func (a Amount) FormatUnits(decimals int) string {
+	text := new(big.Rat).SetFrac(a.Int(), pow10(decimals)).FloatString(decimals)
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	return text
+}
+
+// String represents Amount to its decimal representation
+func (a Amount) String() string {
+	return a.Int().String()
+}
+
+// MarshalJSON encodes the amount as a JSON number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes the amount from a JSON number or string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		*a = NewAmount(0)
+		return nil
+	}
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value stores the amount as its decimal representation
+// Implements the driver.Valuer interface
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads the amount from the database
+// Implements the sql.Scanner interface
+func (a *Amount) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*a = NewAmount(0)
+	case int64:
+		*a = NewAmount(value)
+	case float64:
+		// Amounts stored before exact amounts were introduced
+		integer, _ := new(big.Float).SetFloat64(value).Int(nil)
+		*a = Amount{value: integer}
+	case []byte:
+		return a.Scan(string(value))
+	case string:
+		amount, err := ParseAmount(value)
+		if err != nil {
+			return err
+		}
+		*a = amount
+	default:
+		return fmt.Errorf("Cannot scan amount from %T", src)
+	}
+	return nil
+}
+
+// GormDBDataType returns the column type of amounts for each database
+// SQLite numeric affinity would convert large amounts to floating point, they are stored as text
+func (Amount) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "numeric(78,0)"
+	case "mysql":
+		return "decimal(65,0)"
+	default:
+		return "text"
+	}
+}
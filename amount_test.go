@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+		valid    bool
+	}{
+		{"", "0", true},
+		{"0", "0", true},
+		{" 42 ", "42", true},
+		{"-42", "-42", true},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935", true},
+		{"1e18", "1000000000000000000", true},
+		{"1.5e+18", "1500000000000000000", true},
+		{"1.5E18", "1500000000000000000", true},
+		{"123456789012345678e2", "12345678901234567800", true},
+		{"1.9", "1", true},
+		{"-1.9", "-1", true},
+		{"15e-1", "1", true},
+		{"abc", "", false},
+		{"1.2.3", "", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			amount, err := ParseAmount(tc.text)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("Expected invalid amount, got %s", amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if amount.String() != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		expected string
+	}{
+		{0.05, 18, "50000000000000000"},
+		{0.1, 18, "100000000000000000"},
+		{1.23456789, 8, "123456789"},
+		{0.0000000001, 8, "0"},
+		{2, 12, "2000000000000"},
+	}
+	for _, tc := range tests {
+		if amount := ParseUnits(tc.value, tc.decimals); amount.String() != tc.expected {
+			t.Errorf("Expected %s for %v with %d decimals, got %s", tc.expected, tc.value, tc.decimals, amount)
+		}
+	}
+}
+
+func TestAmountFormatUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		expected string
+	}{
+		{"0", 18, "0"},
+		{"50000000000000000", 18, "0.05"},
+		{"1000000000000000000", 18, "1"},
+		{"1234567890123456789", 18, "1.234567890123456789"},
+		{"1", 18, "0.000000000000000001"},
+		{"-1500000000000", 12, "-1.5"},
+		{"42", 0, "42"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", 18, "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+	for _, tc := range tests {
+		amount, err := ParseAmount(tc.amount)
+		if err != nil {
+			t.Fatalf("Cannot parse amount %s: %v", tc.amount, err)
+		}
+		if text := amount.FormatUnits(tc.decimals); text != tc.expected {
+			t.Errorf("Expected %s for %s with %d decimals, got %s", tc.expected, tc.amount, tc.decimals, text)
+		}
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      interface{}
+		expected string
+		valid    bool
+	}{
+		{"null", nil, "0", true},
+		{"integer", int64(42), "42", true},
+		{"text", "123456789012345678901234567890", "123456789012345678901234567890", true},
+		{"bytes", []byte("123456789012345678901234567890"), "123456789012345678901234567890", true},
+		// Legacy real columns are read as float64 or as text with an exponent
+		{"legacy real", float64(1.5e17), "150000000000000000", true},
+		{"legacy large real", float64(1.2345678901234567e+21), "1234567890123456774144", true},
+		{"legacy real text", "1.5e+17", "150000000000000000", true},
+		{"legacy real bytes", []byte("5.0e+16"), "50000000000000000", true},
+		{"invalid text", "abc", "", false},
+		{"unsupported type", true, "", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var amount Amount
+			err := amount.Scan(tc.src)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("Expected scan error, got %s", amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if amount.String() != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var response struct {
+		Number Amount `json:"number"`
+		String Amount `json:"string"`
+		Float  Amount `json:"float"`
+		Null   Amount `json:"null"`
+	}
+	data := `{"number": 123456789012345678901234567890, "string": "42", "float": 1.5e+18, "null": null}`
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Cannot decode amounts: %v", err)
+	}
+	expected := map[string]Amount{
+		"123456789012345678901234567890": response.Number,
+		"42":                             response.String,
+		"1500000000000000000":            response.Float,
+		"0":                              response.Null,
+	}
+	for text, amount := range expected {
+		if amount.String() != text {
+			t.Errorf("Expected %s, got %s", text, amount)
+		}
+	}
+
+	encoded, err := json.Marshal(response.Number)
+	if err != nil || string(encoded) != "123456789012345678901234567890" {
+		t.Errorf("Expected amount to be encoded as a number, got %s (%v)", encoded, err)
+	}
+}
+
+func TestAmountOperations(t *testing.T) {
+	var zero Amount
+	if !zero.IsZero() || zero.String() != "0" {
+		t.Errorf("Expected zero value to be 0, got %s", zero)
+	}
+	a := NewAmount(100)
+	b := NewAmount(30)
+	if sum := a.Add(b); sum.String() != "130" {
+		t.Errorf("Expected 130, got %s", sum)
+	}
+	if difference := b.Sub(a); difference.String() != "-70" || difference.Sign() != -1 {
+		t.Errorf("Expected -70, got %s", difference)
+	}
+	if product := a.Mul(3); product.String() != "300" {
+		t.Errorf("Expected 300, got %s", product)
+	}
+	if quotient := a.Div(3); quotient.String() != "33" {
+		t.Errorf("Expected 33, got %s", quotient)
+	}
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(NewAmount(100)) != 0 {
+		t.Error("Unexpected comparison result")
+	}
+	if a.String() != "100" || b.String() != "30" {
+		t.Errorf("Expected operations to keep amounts unchanged, got %s and %s", a, b)
+	}
+}
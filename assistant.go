@@ -0,0 +1,560 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Assistant to fetch information from the API, persist it and send notifications
+type Assistant struct {
+	config      *Config
+	db          *gorm.DB
+	client      *FlexpoolClient
+	notifier    Notifier
+	maxPayments int
+	maxBlocks   int
+}
+
+// NewAssistant creates an Assistant
+func NewAssistant(config *Config, db *gorm.DB, client *FlexpoolClient, notifier Notifier) *Assistant {
+	// Limits
+	var maxPayments int
+	if config.MaxPayments > 0 {
+		maxPayments = config.MaxPayments
+	} else {
+		maxPayments = MaxPayments
+	}
+
+	var maxBlocks int
+	if config.MaxBlocks > 0 {
+		maxBlocks = config.MaxBlocks
+	} else {
+		maxBlocks = MaxBlocks
+	}
+
+	return &Assistant{
+		config:      config,
+		db:          db,
+		client:      client,
+		notifier:    notifier,
+		maxPayments: maxPayments,
+		maxBlocks:   maxBlocks,
+	}
+}
+
+// Run handles all configured miners and pools once
+func (a *Assistant) Run() {
+	for _, configuredMiner := range a.config.Miners {
+		a.handleMiner(configuredMiner)
+	}
+	for _, configuredPool := range a.config.Pools {
+		a.handlePool(configuredPool)
+	}
+	for _, configuredReport := range a.config.Reports {
+		if err := a.handleReport(configuredReport); err != nil {
+			log.Warnf("%v", err)
+		}
+	}
+}
+
+// handleMiner handles balance, payments and workers of a miner
+func (a *Assistant) handleMiner(configuredMiner MinerConfig) {
+	miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
+	if err != nil {
+		log.Warnf("Could not parse miner: %v", err)
+		return
+	}
+
+	var dbMiner Miner
+	trx := a.db.Where(Miner{Address: miner.Address}).Attrs(Miner{Address: miner.Address, Coin: miner.Coin}).FirstOrCreate(&dbMiner)
+	if trx.Error != nil {
+		log.Warnf("Cannot fetch miner %s from database: %v", miner, trx.Error)
+	}
+
+	// Stats are informative, a failure must not prevent balance, payments and workers notifications
+	if configuredMiner.EnableStats {
+		if err := a.handleStats(miner); err != nil {
+			log.Warnf("%v", err)
+		}
+	}
+
+	if configuredMiner.EnableBalance {
+		if err := a.handleBalance(configuredMiner, miner, &dbMiner); err != nil {
+			log.Warnf("%v", err)
+			return
+		}
+	}
+
+	if configuredMiner.EnablePayments {
+		if err := a.handlePayments(miner, &dbMiner); err != nil {
+			log.Warnf("%v", err)
+			return
+		}
+	}
+
+	if configuredMiner.EnablePayoutSettings {
+		if err := a.handlePayoutSettings(miner, &dbMiner); err != nil {
+			log.Warnf("%v", err)
+			return
+		}
+	}
+
+	if configuredMiner.EnableOfflineWorkers {
+		if err := a.handleWorkers(configuredMiner, miner); err != nil {
+			log.Warnf("%v", err)
+			return
+		}
+	}
+}
+
+// handleStats fetches hashrates and shares of a miner and records them
+func (a *Assistant) handleStats(miner *Miner) error {
+	log.Debugf("Fetching stats for %s", miner)
+	stats, err := a.client.MinerStats(miner.Coin, miner.Address)
+	if err != nil {
+		return fmt.Errorf("Could not fetch stats: %v", err)
+	}
+	if trx := a.db.Create(stats); trx.Error != nil {
+		return fmt.Errorf("Cannot record stats: %v", trx.Error)
+	}
+	miner.Stats = *stats
+	return nil
+}
+
+// handleBalance fetches the unpaid balance and sends notifications when it has changed or when alerts are triggered
+func (a *Assistant) handleBalance(configuredMiner MinerConfig, miner *Miner, dbMiner *Miner) error {
+	// Balance have never been persisted, skip notifications
+	notify := true
+	if dbMiner.Balance.IsZero() {
+		notify = false
+	}
+
+	log.Debugf("Fetching balance for %s", miner)
+	balance, err := a.client.MinerBalance(miner.Coin, miner.Address)
+	if err != nil {
+		return fmt.Errorf("Could not fetch unpaid balance: %v", err)
+	}
+	log.Debugf("Unpaid balance %s", balance)
+	miner.Balance = balance
+	if trx := a.db.Create(NewBalanceRecord(miner.Address, balance)); trx.Error != nil {
+		return fmt.Errorf("Cannot record balance: %v", trx.Error)
+	}
+
+	alerts := a.balanceAlerts(configuredMiner.BalanceAlerts, miner, dbMiner)
+
+	// Without minimum increase, notify on every change
+	notifyBalance := miner.Balance.Cmp(dbMiner.Balance) != 0
+	if configuredMiner.BalanceAlerts.MinIncrease > 0 {
+		// Balance has been paid, start over from the new balance
+		if miner.Balance.Cmp(dbMiner.LastNotifiedBalance) < 0 {
+			dbMiner.LastNotifiedBalance = miner.Balance
+		}
+		minIncrease, err := ParseCurrency(miner.Coin, configuredMiner.BalanceAlerts.MinIncrease)
+		if err != nil {
+			return fmt.Errorf("Minimum balance increase cannot be converted: %v", err)
+		}
+		notifyBalance = miner.Balance.Sub(dbMiner.LastNotifiedBalance).Cmp(minIncrease) >= 0
+	}
+	if notifyBalance {
+		dbMiner.LastNotifiedBalance = miner.Balance
+	}
+
+	dbMiner.Balance = miner.Balance
+	if trx := a.db.Save(dbMiner); trx.Error != nil {
+		return fmt.Errorf("Cannot update miner: %v", trx.Error)
+	}
+
+	if !notify {
+		return nil
+	}
+	if notifyBalance {
+		if err = a.estimatePayout(miner); err != nil {
+			log.Debugf("Cannot estimate payout of %s: %v", miner, err)
+		}
+		if err = a.notifier.NotifyBalance(*miner); err != nil {
+			return fmt.Errorf("Cannot send notification: %v", err)
+		}
+		log.Infof("Balance notification sent for %s", miner)
+	}
+	for _, alert := range alerts {
+		if err = a.notifier.NotifyBalanceAlert(*miner, *alert); err != nil {
+			return fmt.Errorf("Cannot send notification: %v", err)
+		}
+		log.Infof("Balance alert notification sent for %s (%s)", miner, alert)
+	}
+	return nil
+}
+
+// balanceAlerts returns alerts triggered by the new balance of the miner and updates the stall detection state
+func (a *Assistant) balanceAlerts(configuredAlerts BalanceAlertsConfig, miner *Miner, dbMiner *Miner) (alerts []*BalanceAlert) {
+	now := time.Now()
+
+	if miner.Balance.Cmp(dbMiner.Balance) > 0 || dbMiner.BalanceIncreasedAt == nil {
+		dbMiner.BalanceIncreasedAt = &now
+		dbMiner.BalanceStalled = false
+	}
+
+	if len(configuredAlerts.Thresholds) > 0 {
+		for _, threshold := range configuredAlerts.Thresholds {
+			limit, err := ParseCurrency(miner.Coin, threshold)
+			if err != nil {
+				log.Warnf("Balance threshold cannot be converted: %v", err)
+				continue
+			}
+			if dbMiner.Balance.Cmp(limit) < 0 && miner.Balance.Cmp(limit) >= 0 {
+				alerts = append(alerts, &BalanceAlert{Type: BalanceAlertThreshold, Threshold: threshold})
+			}
+		}
+	}
+
+	stalledFor := now.Sub(*dbMiner.BalanceIncreasedAt)
+	if configuredAlerts.StalledAfter > 0 && !dbMiner.BalanceStalled && stalledFor >= configuredAlerts.StalledAfter {
+		dbMiner.BalanceStalled = true
+		alerts = append(alerts, &BalanceAlert{Type: BalanceAlertStalled, StalledFor: stalledFor.Round(time.Minute)})
+	}
+	return alerts
+}
+
+// handlePayments fetches last payments, persists them and sends a notification for each new one
+func (a *Assistant) handlePayments(miner *Miner, dbMiner *Miner) error {
+	var knownPayments int64
+	if trx := a.db.Model(&Payment{}).Where("miner_address = ?", miner.Address).Count(&knownPayments); trx.Error != nil {
+		return fmt.Errorf("Cannot count payments: %v", trx.Error)
+	}
+
+	// Payments have never been persisted, skip notifications
+	notify := true
+	if knownPayments == 0 && dbMiner.LastPaymentTimestamp == 0 {
+		notify = false
+	}
+	lastPaymentTimestamp := dbMiner.LastPaymentTimestamp
+
+	log.Debugf("Fetching payments for %s", miner)
+	payments, err := a.client.MinerPayments(miner.Coin, miner.Address, a.maxPayments)
+	if err != nil {
+		return fmt.Errorf("Could not fetch payments: %v", err)
+	}
+	for _, payment := range payments {
+		log.Debugf("Fetched %s", payment)
+
+		var dbPayment Payment
+		trx := a.db.Where(Payment{MinerAddress: miner.Address, Hash: payment.Hash}).Limit(1).Find(&dbPayment)
+		if trx.Error != nil {
+			log.Warnf("Cannot fetch payment %s from database: %v", payment, trx.Error)
+			continue
+		}
+
+		// Payment is already known, only follow its confirmation
+		if dbPayment.ID != 0 {
+			if dbPayment.Confirmed != payment.Confirmed {
+				dbPayment.Confirmed = payment.Confirmed
+				if trx = a.db.Save(&dbPayment); trx.Error != nil {
+					log.Warnf("Cannot update payment: %v", trx.Error)
+				}
+			}
+			continue
+		}
+
+		if trx = a.db.Create(payment); trx.Error != nil {
+			log.Warnf("Cannot create payment: %v", trx.Error)
+			continue
+		}
+		if dbMiner.LastPaymentTimestamp < payment.Timestamp {
+			dbMiner.LastPaymentTimestamp = payment.Timestamp
+			if trx = a.db.Save(dbMiner); trx.Error != nil {
+				log.Warnf("Cannot update miner: %v", trx.Error)
+			}
+		}
+
+		// Payments table has just been created, skip payments notified before its creation
+		if knownPayments == 0 && payment.Timestamp <= lastPaymentTimestamp {
+			continue
+		}
+
+		if notify {
+			if err = a.notifier.NotifyPayment(*miner, *payment); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+				continue
+			}
+			log.Infof("Payment notification sent for %s", payment)
+		}
+	}
+	return nil
+}
+
+// handlePayoutSettings fetches payout settings of a miner and sends a notification when they have changed
+func (a *Assistant) handlePayoutSettings(miner *Miner, dbMiner *Miner) error {
+	// Settings have never been persisted, skip notifications
+	notify := true
+	if dbMiner.PayoutLimit.IsZero() && dbMiner.PayoutNetwork == "" {
+		notify = false
+	}
+
+	log.Debugf("Fetching payout settings for %s", miner)
+	details, err := a.client.MinerDetails(miner.Coin, miner.Address)
+	if err != nil {
+		return fmt.Errorf("Could not fetch payout settings: %v", err)
+	}
+
+	miner.PayoutLimit = details.PayoutLimit
+	miner.MaxFeePrice = details.MaxFeePrice
+	miner.PayoutNetwork = details.Network
+
+	var changes []SettingChange
+	if details.PayoutLimit.Cmp(dbMiner.PayoutLimit) != 0 {
+		previous, _ := ConvertCurrency(miner.Coin, dbMiner.PayoutLimit)
+		current, _ := ConvertCurrency(miner.Coin, details.PayoutLimit)
+		changes = append(changes, SettingChange{
+			Name:     "payout limit",
+			Previous: fmt.Sprintf("%.6f", previous),
+			Current:  fmt.Sprintf("%.6f", current),
+		})
+	}
+	if details.MaxFeePrice != dbMiner.MaxFeePrice {
+		changes = append(changes, SettingChange{
+			Name:     "max fee price",
+			Previous: fmt.Sprintf("%g", dbMiner.MaxFeePrice),
+			Current:  fmt.Sprintf("%g", details.MaxFeePrice),
+		})
+	}
+	if details.Network != dbMiner.PayoutNetwork {
+		changes = append(changes, SettingChange{
+			Name:     "network",
+			Previous: dbMiner.PayoutNetwork,
+			Current:  details.Network,
+		})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	dbMiner.PayoutLimit = details.PayoutLimit
+	dbMiner.MaxFeePrice = details.MaxFeePrice
+	dbMiner.PayoutNetwork = details.Network
+	if trx := a.db.Save(dbMiner); trx.Error != nil {
+		return fmt.Errorf("Cannot update miner: %v", trx.Error)
+	}
+	if notify {
+		log.Warnf("Payout settings of %s have changed: %v", miner, changes)
+		if err = a.notifier.NotifyPayoutSettings(*miner, changes); err != nil {
+			return fmt.Errorf("Cannot send notification: %v", err)
+		}
+		log.Infof("Payout settings notification sent for %s", miner)
+	}
+	return nil
+}
+
+// handlePool handles blocks of a pool
+func (a *Assistant) handlePool(configuredPool PoolConfig) {
+	pool := NewPool(configuredPool.Coin)
+
+	var dbPool Pool
+	trx := a.db.Where(Pool{Coin: pool.Coin}).Attrs(Pool{Coin: pool.Coin}).FirstOrCreate(&dbPool)
+	if trx.Error != nil {
+		log.Warnf("Cannot fetch pool %s from database: %v", pool, trx.Error)
+	}
+
+	if configuredPool.EnableStats {
+		if err := a.handlePoolStats(configuredPool, pool, &dbPool); err != nil {
+			log.Warnf("%v", err)
+		}
+	}
+
+	if configuredPool.EnableBlocks {
+		if err := a.handleBlocks(configuredPool, pool, &dbPool); err != nil {
+			log.Warnf("%v", err)
+		}
+	}
+}
+
+// handlePoolStats fetches pool statistics and sends a notification when one of them crosses its threshold
+func (a *Assistant) handlePoolStats(configuredPool PoolConfig, pool *Pool, dbPool *Pool) error {
+	// Statistics have never been persisted, skip notifications
+	notify := true
+	if dbPool.NetworkDifficulty == 0 {
+		notify = false
+	}
+
+	log.Debugf("Fetching stats for %s", pool)
+	stats, err := a.client.PoolStats(pool.Coin)
+	if err != nil {
+		return fmt.Errorf("Could not fetch pool stats: %v", err)
+	}
+	pool.Hashrate = stats.Hashrate
+	pool.CurrentEffort = stats.CurrentEffort
+	pool.AverageLuck = stats.AverageLuck
+	pool.NetworkDifficulty = stats.NetworkDifficulty
+
+	// Luck and effort are ratios, thresholds are percentages
+	alerts := configuredPool.StatsAlerts
+	var triggered []PoolAlert
+	if limit := alerts.MaxEffort; limit > 0 && dbPool.CurrentEffort*100 <= limit && pool.CurrentEffort*100 > limit {
+		triggered = append(triggered, PoolAlert{Type: PoolAlertEffort, Value: pool.CurrentEffort * 100, Threshold: limit})
+	}
+	if limit := alerts.MinAverageLuck; limit > 0 && dbPool.AverageLuck*100 >= limit && pool.AverageLuck*100 < limit {
+		triggered = append(triggered, PoolAlert{Type: PoolAlertAverageLuck, Value: pool.AverageLuck * 100, Threshold: limit})
+	}
+	if limit := alerts.MinHashrate; limit > 0 && dbPool.Hashrate >= limit && pool.Hashrate < limit {
+		triggered = append(triggered, PoolAlert{Type: PoolAlertHashrate, Value: pool.Hashrate, Threshold: limit})
+	}
+	if limit := alerts.MaxNetworkDifficulty; limit > 0 && dbPool.NetworkDifficulty <= limit && pool.NetworkDifficulty > limit {
+		triggered = append(triggered, PoolAlert{Type: PoolAlertNetworkDifficulty, Value: pool.NetworkDifficulty, Threshold: limit})
+	}
+
+	dbPool.Hashrate = pool.Hashrate
+	dbPool.CurrentEffort = pool.CurrentEffort
+	dbPool.AverageLuck = pool.AverageLuck
+	dbPool.NetworkDifficulty = pool.NetworkDifficulty
+	if trx := a.db.Save(dbPool); trx.Error != nil {
+		return fmt.Errorf("Cannot update pool: %v", trx.Error)
+	}
+
+	if !notify {
+		return nil
+	}
+	for _, alert := range triggered {
+		if err = a.notifier.NotifyPoolStats(*pool, alert); err != nil {
+			return fmt.Errorf("Cannot send notification: %v", err)
+		}
+		log.Infof("Pool stats notification sent for %s (%s)", pool, &alert)
+	}
+	return nil
+}
+
+// handleBlocks fetches last blocks, persists them and sends a notification for each new one
+func (a *Assistant) handleBlocks(configuredPool PoolConfig, pool *Pool, dbPool *Pool) error {
+	var knownBlocks int64
+	if trx := a.db.Model(&Block{}).Where("coin = ?", pool.Coin).Count(&knownBlocks); trx.Error != nil {
+		return fmt.Errorf("Cannot count blocks: %v", trx.Error)
+	}
+
+	// Blocks have never been persisted, skip notifications
+	notify := true
+	if knownBlocks == 0 && dbPool.LastBlockNumber == 0 {
+		notify = false
+	}
+	lastBlockNumber := dbPool.LastBlockNumber
+
+	log.Debugf("Fetching blocks for %s", pool)
+	blocks, err := a.client.PoolBlocks(pool.Coin, a.maxBlocks)
+	if err != nil {
+		return fmt.Errorf("Could not fetch blocks: %v", err)
+	}
+	for _, block := range blocks {
+		log.Debugf("Fetched %s", block)
+
+		var dbBlock Block
+		trx := a.db.Where(Block{Coin: pool.Coin, Hash: block.Hash}).Limit(1).Find(&dbBlock)
+		if trx.Error != nil {
+			log.Warnf("Cannot fetch block %s from database: %v", block, trx.Error)
+			continue
+		}
+
+		// Block is already known, only follow its status
+		if dbBlock.ID != 0 {
+			if err = a.handleBlockStatus(pool, &dbBlock, block); err != nil {
+				log.Warnf("%v", err)
+			}
+			continue
+		}
+
+		if trx = a.db.Create(block); trx.Error != nil {
+			log.Warnf("Cannot create block: %v", trx.Error)
+			continue
+		}
+		if dbPool.LastBlockNumber < block.Number {
+			dbPool.LastBlockNumber = block.Number
+			if trx = a.db.Save(dbPool); trx.Error != nil {
+				log.Warnf("Cannot update pool: %v", trx.Error)
+			}
+		}
+
+		// Blocks table has just been created, skip blocks notified before its creation
+		if knownBlocks == 0 && block.Number <= lastBlockNumber {
+			continue
+		}
+
+		if !notify {
+			continue
+		}
+
+		// Blocks found by our miners are always notified, regardless of the reward
+		if miner := a.blockMiner(pool, block); miner != nil {
+			if err = a.notifier.NotifyMinerBlock(*pool, *miner, *block); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+				continue
+			}
+			log.Infof("Miner block notification sent for %s (%s)", block, miner)
+		} else {
+			minReward, err := ParseCurrency(pool.Coin, configuredPool.MinBlockReward)
+			if err != nil {
+				log.Warnf("Minimum block reward cannot be converted: %v", err)
+			}
+			if block.Reward.Cmp(minReward) < 0 {
+				continue
+			}
+			if err = a.notifier.NotifyBlock(*pool, *block); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+				continue
+			}
+			log.Infof("Block notification sent for %s", block)
+		}
+
+		block.Notified = true
+		if trx = a.db.Save(block); trx.Error != nil {
+			log.Warnf("Cannot update block: %v", trx.Error)
+		}
+	}
+	return nil
+}
+
+// blockMiner returns the configured miner who found the block or nil
+func (a *Assistant) blockMiner(pool *Pool, block *Block) *Miner {
+	if block.MinerAddress == "" {
+		return nil
+	}
+	for _, configuredMiner := range a.config.Miners {
+		// Ethereum addresses are case insensitive
+		if !strings.EqualFold(configuredMiner.Address, block.MinerAddress) {
+			continue
+		}
+		miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
+		if err != nil {
+			log.Warnf("Could not parse miner: %v", err)
+			continue
+		}
+		if miner.Coin == pool.Coin {
+			return miner
+		}
+	}
+	return nil
+}
+
+// handleBlockStatus updates a known block and sends a notification when an announced block is confirmed or orphaned
+func (a *Assistant) handleBlockStatus(pool *Pool, dbBlock *Block, block *Block) error {
+	confirmed := !dbBlock.Confirmed && block.Confirmed
+	orphaned := dbBlock.Type != BlockTypeOrphan && block.Type == BlockTypeOrphan
+	if !confirmed && !orphaned {
+		return nil
+	}
+
+	dbBlock.Type = block.Type
+	dbBlock.Confirmed = block.Confirmed
+	dbBlock.Reward = block.Reward
+	dbBlock.Luck = block.Luck
+	if trx := a.db.Save(dbBlock); trx.Error != nil {
+		return fmt.Errorf("Cannot update block: %v", trx.Error)
+	}
+
+	if dbBlock.Notified {
+		if err := a.notifier.NotifyBlockStatus(*pool, *dbBlock); err != nil {
+			return fmt.Errorf("Cannot send notification: %v", err)
+		}
+		log.Infof("Block status notification sent for %s", dbBlock)
+	}
+	return nil
+}
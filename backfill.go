@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BackfillDelay defaults between two requests of the backfill command to respect the API rate limits
+const BackfillDelay = time.Second
+
+// commandBackfill walks all pages of payments of configured miners and blocks of configured pools, when they are
+// enabled, to populate the history without sending notifications
+func (a *Assistant) commandBackfill(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	delay := flags.Duration("delay", BackfillDelay, "Delay between two requests to the API")
+	from := flags.String("from", "", "Stop at records older than this date (ex: 2021-01-01 or 2021-01-01T00:00:00Z)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	since, err := parseCommandDate(*from)
+	if err != nil {
+		return err
+	}
+
+	kind := "all"
+	if flags.NArg() > 0 {
+		kind = flags.Arg(0)
+	}
+	if kind != "all" && kind != "payments" && kind != "blocks" {
+		return fmt.Errorf("Unknown kind of records %s (available: payments, blocks)", kind)
+	}
+
+	if kind == "all" || kind == "payments" {
+		for _, configuredMiner := range a.config.Miners {
+			if !configuredMiner.EnablePayments {
+				continue
+			}
+			miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
+			if err != nil {
+				return err
+			}
+			if err = a.backfillPayments(miner, since, *delay); err != nil {
+				return err
+			}
+		}
+	}
+	if kind == "all" || kind == "blocks" {
+		for _, configuredPool := range a.config.Pools {
+			if !configuredPool.EnableBlocks {
+				continue
+			}
+			if err = a.backfillBlocks(NewPool(configuredPool.Coin), since, *delay); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backfillPayments stores all payments of a miner, unknown payments are considered as already notified
+func (a *Assistant) backfillPayments(miner *Miner, since time.Time, delay time.Duration) error {
+	var dbMiner Miner
+	trx := a.db.Where(Miner{Address: miner.Address}).Attrs(Miner{Address: miner.Address, Coin: miner.Coin}).FirstOrCreate(&dbMiner)
+	if trx.Error != nil {
+		return fmt.Errorf("Cannot fetch miner %s from database: %v", miner, trx.Error)
+	}
+
+	created := 0
+	for page, totalPages := 0, 1; page < totalPages; page++ {
+		if page > 0 {
+			time.Sleep(delay)
+		}
+		log.Debugf("Fetching payments page %d for %s", page, miner)
+		payments, pages, err := a.client.MinerPaymentsPage(miner.Coin, miner.Address, page)
+		if err != nil {
+			return fmt.Errorf("Could not fetch payments: %v", err)
+		}
+		totalPages = pages
+
+		for _, payment := range payments {
+			if !since.IsZero() && payment.Timestamp < since.Unix() {
+				totalPages = 0
+				break
+			}
+
+			var dbPayment Payment
+			if trx = a.db.Where(Payment{MinerAddress: miner.Address, Hash: payment.Hash}).Limit(1).Find(&dbPayment); trx.Error != nil {
+				return fmt.Errorf("Cannot fetch payment %s from database: %v", payment, trx.Error)
+			}
+			if dbPayment.ID != 0 {
+				continue
+			}
+			if trx = a.db.Create(payment); trx.Error != nil {
+				return fmt.Errorf("Cannot create payment: %v", trx.Error)
+			}
+			created++
+
+			if dbMiner.LastPaymentTimestamp < payment.Timestamp {
+				dbMiner.LastPaymentTimestamp = payment.Timestamp
+				if trx = a.db.Save(&dbMiner); trx.Error != nil {
+					return fmt.Errorf("Cannot update miner: %v", trx.Error)
+				}
+			}
+		}
+	}
+	log.Infof("Backfilled %d payments for %s", created, miner)
+	return nil
+}
+
+// backfillBlocks stores all blocks of a pool, unknown blocks are stored as not notified to skip their status
+// notifications
+func (a *Assistant) backfillBlocks(pool *Pool, since time.Time, delay time.Duration) error {
+	var dbPool Pool
+	trx := a.db.Where(Pool{Coin: pool.Coin}).Attrs(Pool{Coin: pool.Coin}).FirstOrCreate(&dbPool)
+	if trx.Error != nil {
+		return fmt.Errorf("Cannot fetch pool %s from database: %v", pool, trx.Error)
+	}
+
+	created := 0
+	for page, totalPages := 0, 1; page < totalPages; page++ {
+		if page > 0 {
+			time.Sleep(delay)
+		}
+		log.Debugf("Fetching blocks page %d for %s", page, pool)
+		blocks, pages, err := a.client.PoolBlocksPage(pool.Coin, page)
+		if err != nil {
+			return fmt.Errorf("Could not fetch blocks: %v", err)
+		}
+		totalPages = pages
+
+		for _, block := range blocks {
+			if !since.IsZero() && block.Timestamp < since.Unix() {
+				totalPages = 0
+				break
+			}
+
+			var dbBlock Block
+			if trx = a.db.Where(Block{Coin: pool.Coin, Hash: block.Hash}).Limit(1).Find(&dbBlock); trx.Error != nil {
+				return fmt.Errorf("Cannot fetch block %s from database: %v", block, trx.Error)
+			}
+			if dbBlock.ID != 0 {
+				continue
+			}
+			if trx = a.db.Create(block); trx.Error != nil {
+				return fmt.Errorf("Cannot create block: %v", trx.Error)
+			}
+			created++
+
+			if dbPool.LastBlockNumber < block.Number {
+				dbPool.LastBlockNumber = block.Number
+				if trx = a.db.Save(&dbPool); trx.Error != nil {
+					return fmt.Errorf("Cannot update pool: %v", trx.Error)
+				}
+			}
+		}
+	}
+	log.Infof("Backfilled %d blocks for %s", created, pool)
+	return nil
+}
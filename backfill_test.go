@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backfillPages is the number of pages of payments and blocks served by fakeBackfillAPI
+const backfillPages = 3
+
+// fakeBackfillAPI serves pages of two payments and two blocks, from the most recent to the oldest, one hour apart
+type fakeBackfillAPI struct {
+	start    time.Time
+	requests map[string]int
+}
+
+// timestamp returns the time of the nth most recent record
+func (f *fakeBackfillAPI) timestamp(n int) int64 {
+	return f.start.Add(-time.Duration(n) * time.Hour).Unix()
+}
+
+func (f *fakeBackfillAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests[r.URL.Path]++
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	var items []string
+	switch r.URL.Path {
+	case "/v2/miner/payments/":
+		for n := page * 2; n < page*2+2; n++ {
+			items = append(items, fmt.Sprintf(`{"hash": "0xpayment%d", "value": 100000000000000000, "fee": 0, "timestamp": %d, "confirmed": true}`, n, f.timestamp(n)))
+		}
+	case "/v2/pool/blocks/":
+		for n := page * 2; n < page*2+2; n++ {
+			items = append(items, fmt.Sprintf(`{"hash": "0xblock%d", "number": %d, "type": "block", "miner": "%s", "reward": 2000000000000000000, "confirmed": true, "timestamp": %d}`, n, 13000000-n, testAddress, f.timestamp(n)))
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"error": null, "result": {"totalPages": %d, "data": [%s]}}`, backfillPages, strings.Join(items, ","))
+}
+
+func TestCommandBackfill(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	tests := []struct {
+		name             string
+		args             []string
+		disablePayments  bool
+		known            int
+		expectedPayments int64
+		expectedBlocks   int64
+		expectedRequests int
+	}{
+		{name: "all", args: []string{}, expectedPayments: 6, expectedBlocks: 6, expectedRequests: 2 * backfillPages},
+		{name: "payments", args: []string{"payments"}, expectedPayments: 6, expectedRequests: backfillPages},
+		{name: "blocks", args: []string{"blocks"}, expectedBlocks: 6, expectedRequests: backfillPages},
+		// Records older than the date stop the walk through pages
+		{name: "from", args: []string{"-from", start.Add(-150 * time.Minute).Format(time.RFC3339), "payments"}, expectedPayments: 3, expectedRequests: 2},
+		{name: "payments disabled", args: []string{"payments"}, disablePayments: true},
+		{name: "known payments", args: []string{"payments"}, known: 2, expectedPayments: 6, expectedRequests: backfillPages},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+			api := &fakeBackfillAPI{start: start, requests: make(map[string]int)}
+			// Known payments have been recorded by previous runs
+			if tc.known > 0 {
+				if trx := db.Create(&Miner{Address: testAddress, Coin: "eth", LastPaymentTimestamp: api.timestamp(0)}); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+			}
+			for n := 0; n < tc.known; n++ {
+				if trx := db.Create(NewPayment(testAddress, fmt.Sprintf("0xpayment%d", n), NewAmount(1), NewAmount(0), api.timestamp(n), true)); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+			}
+
+			config := NewConfig()
+			config.Miners = []MinerConfig{{Address: testAddress, Coin: "eth", EnablePayments: !tc.disablePayments}}
+			config.Pools = []PoolConfig{{Coin: "eth", EnableBlocks: true}}
+			assistant := NewAssistant(config, db, newTestClient(t, api), nil)
+			if err := assistant.commandBackfill(append([]string{"-delay", "0"}, tc.args...)); err != nil {
+				t.Fatalf("Cannot backfill: %v", err)
+			}
+
+			var payments, blocks int64
+			if trx := db.Model(&Payment{}).Count(&payments); trx.Error != nil {
+				t.Fatal(trx.Error)
+			}
+			if trx := db.Model(&Block{}).Count(&blocks); trx.Error != nil {
+				t.Fatal(trx.Error)
+			}
+			if payments != tc.expectedPayments || blocks != tc.expectedBlocks {
+				t.Errorf("Expected %d payments and %d blocks, got %d and %d", tc.expectedPayments, tc.expectedBlocks, payments, blocks)
+			}
+			requests := 0
+			for _, count := range api.requests {
+				requests += count
+			}
+			if requests != tc.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+
+			// Backfilled records are considered as already notified
+			if tc.expectedPayments > 0 {
+				var miner Miner
+				if trx := db.Where(Miner{Address: testAddress}).First(&miner); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+				if miner.LastPaymentTimestamp != api.timestamp(0) {
+					t.Errorf("Expected last payment timestamp %d, got %d", api.timestamp(0), miner.LastPaymentTimestamp)
+				}
+			}
+			if tc.expectedBlocks > 0 {
+				var pool Pool
+				if trx := db.Where(Pool{Coin: "eth"}).First(&pool); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+				if pool.LastBlockNumber != 13000000 {
+					t.Errorf("Expected last block number 13000000, got %d", pool.LastBlockNumber)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandBackfillInvalidArguments(t *testing.T) {
+	assistant := NewAssistant(NewConfig(), newTestDatabase(t), nil, nil)
+	for _, args := range [][]string{{"workers"}, {"-from", "yesterday"}} {
+		if err := assistant.commandBackfill(args); err == nil {
+			t.Errorf("Expected backfill with %v to fail", args)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"sort"
 	"time"
 
@@ -67,15 +68,15 @@ func (f *FlexpoolClient) request(url string) ([]byte, error) {
 type BalanceResponse struct {
 	Error  string `json:"error"`
 	Result struct {
-		Balance float64 `json:"balance"`
+		Balance Amount `json:"balance"`
 	} `json:"result"`
 }
 
 // MinerBalance returns the current unpaid balance
-func (f *FlexpoolClient) MinerBalance(coin string, address string) (float64, error) {
+func (f *FlexpoolClient) MinerBalance(coin string, address string) (Amount, error) {
 	body, err := f.request(fmt.Sprintf("%s/miner/balance?coin=%s&address=%s", FlexpoolAPIURL, coin, address))
 	if err != nil {
-		return 0, err
+		return Amount{}, err
 	}
 
 	var response BalanceResponse
@@ -83,15 +84,96 @@ func (f *FlexpoolClient) MinerBalance(coin string, address string) (float64, err
 	return response.Result.Balance, nil
 }
 
+// MinerStatsResponse represents the JSON structure of the Flexpool API response for miner stats
+type MinerStatsResponse struct {
+	Error  string `json:"error"`
+	Result struct {
+		CurrentEffectiveHashrate float64 `json:"currentEffectiveHashrate"`
+		AverageEffectiveHashrate float64 `json:"averageEffectiveHashrate"`
+		ReportedHashrate         float64 `json:"reportedHashrate"`
+		ValidShares              uint64  `json:"validShares"`
+		StaleShares              uint64  `json:"staleShares"`
+		InvalidShares            uint64  `json:"invalidShares"`
+	} `json:"result"`
+}
+
+// MinerStats returns current hashrates and shares of a miner
+func (f *FlexpoolClient) MinerStats(coin string, address string) (*MinerStats, error) {
+	body, err := f.request(fmt.Sprintf("%s/miner/stats?coin=%s&address=%s", FlexpoolAPIURL, coin, address))
+	if err != nil {
+		return nil, err
+	}
+
+	var response MinerStatsResponse
+	json.Unmarshal(body, &response)
+	return NewMinerStats(
+		address,
+		response.Result.CurrentEffectiveHashrate,
+		response.Result.AverageEffectiveHashrate,
+		response.Result.ReportedHashrate,
+		response.Result.ValidShares,
+		response.Result.StaleShares,
+		response.Result.InvalidShares,
+	), nil
+}
+
+// MinerDetailsResponse represents the JSON structure of the Flexpool API response for miner details
+type MinerDetailsResponse struct {
+	Error  string        `json:"error"`
+	Result *MinerDetails `json:"result"`
+}
+
+// MinerDetails to store payout settings of a miner
+type MinerDetails struct {
+	PayoutLimit Amount  `json:"payoutLimit"`
+	MaxFeePrice float64 `json:"maxFeePrice"`
+	Network     string  `json:"network"`
+}
+
+// MinerDetails returns payout settings of a miner
+func (f *FlexpoolClient) MinerDetails(coin string, address string) (*MinerDetails, error) {
+	body, err := f.request(fmt.Sprintf("%s/miner/details?coin=%s&address=%s", FlexpoolAPIURL, coin, address))
+	if err != nil {
+		return nil, err
+	}
+
+	var response MinerDetailsResponse
+	json.Unmarshal(body, &response)
+	if response.Result == nil {
+		return nil, fmt.Errorf("No details found for miner %s", address)
+	}
+	return response.Result, nil
+}
+
+// EstimatedDailyRevenueResponse represents the JSON structure of the Flexpool API response for estimated daily revenue
+type EstimatedDailyRevenueResponse struct {
+	Error  string `json:"error"`
+	Result Amount `json:"result"`
+}
+
+// MinerEstimatedDailyRevenue returns the daily revenue of a miner estimated by the pool
+func (f *FlexpoolClient) MinerEstimatedDailyRevenue(coin string, address string) (Amount, error) {
+	body, err := f.request(fmt.Sprintf("%s/miner/estimatedDailyRevenue?coin=%s&address=%s", FlexpoolAPIURL, coin, address))
+	if err != nil {
+		return Amount{}, err
+	}
+
+	var response EstimatedDailyRevenueResponse
+	json.Unmarshal(body, &response)
+	return response.Result, nil
+}
+
 // PaymentsResponse represents the JSON structure of the Flexpool API response for payments
 type PaymentsResponse struct {
 	Error  string `json:"error"`
 	Result struct {
 		TotalPages int `json:"totalPages"`
 		Data       []struct {
-			Hash      string  `json:"hash"`
-			Value     float64 `json:"value"`
-			Timestamp int64   `json:"timestamp"`
+			Hash      string `json:"hash"`
+			Value     Amount `json:"value"`
+			Fee       Amount `json:"fee"`
+			Timestamp int64  `json:"timestamp"`
+			Confirmed bool   `json:"confirmed"`
 		} `json:"data"`
 	} `json:"result"`
 }
@@ -102,24 +184,16 @@ func (f *FlexpoolClient) MinerPayments(coin string, address string, limit int) (
 	totalPages := 0
 
 	for page <= MaxIterations && len(payments) < limit {
-		body, err := f.request(fmt.Sprintf("%s/miner/payments/?coin=%s&address=%s&page=%d", FlexpoolAPIURL, coin, address, page))
+		pagePayments, pages, err := f.MinerPaymentsPage(coin, address, page)
 		if err != nil {
 			return nil, err
 		}
 
-		var response PaymentsResponse
-		json.Unmarshal(body, &response)
-
 		if totalPages == 0 {
-			totalPages = response.Result.TotalPages
+			totalPages = pages
 		}
 
-		for _, result := range response.Result.Data {
-			payment := NewPayment(
-				result.Hash,
-				result.Value,
-				result.Timestamp,
-			)
+		for _, payment := range pagePayments {
 			payments = append(payments, payment)
 			if len(payments) >= limit {
 				break
@@ -142,6 +216,29 @@ func (f *FlexpoolClient) MinerPayments(coin string, address string, limit int) (
 	return payments, nil
 }
 
+// MinerPaymentsPage returns payments of a page, from the most recent, and the total number of pages
+func (f *FlexpoolClient) MinerPaymentsPage(coin string, address string, page int) (payments []*Payment, totalPages int, err error) {
+	body, err := f.request(fmt.Sprintf("%s/miner/payments/?coin=%s&address=%s&page=%d", FlexpoolAPIURL, coin, address, page))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response PaymentsResponse
+	json.Unmarshal(body, &response)
+
+	for _, result := range response.Result.Data {
+		payments = append(payments, NewPayment(
+			address,
+			result.Hash,
+			result.Value,
+			result.Fee,
+			result.Timestamp,
+			result.Confirmed,
+		))
+	}
+	return payments, response.Result.TotalPages, nil
+}
+
 // LastMinerPayment return the last payment of a miner
 func (f *FlexpoolClient) LastMinerPayment(miner *Miner) (*Payment, error) {
 	log.Debugf("Fetching last payment of %s", miner)
@@ -156,9 +253,15 @@ func (f *FlexpoolClient) LastMinerPayment(miner *Miner) (*Payment, error) {
 type WorkersResponse struct {
 	Error  string `json:"error"`
 	Result []struct {
-		Name      string `json:"name"`
-		IsOnline  bool   `json:"isOnline"`
-		LastSteen int64  `json:"lastSeen"`
+		Name                     string  `json:"name"`
+		IsOnline                 bool    `json:"isOnline"`
+		LastSteen                int64   `json:"lastSeen"`
+		ReportedHashrate         float64 `json:"reportedHashrate"`
+		CurrentEffectiveHashrate float64 `json:"currentEffectiveHashrate"`
+		AverageEffectiveHashrate float64 `json:"averageEffectiveHashrate"`
+		ValidShares              uint64  `json:"validShares"`
+		StaleShares              uint64  `json:"staleShares"`
+		InvalidShares            uint64  `json:"invalidShares"`
 	} `json:"result"`
 }
 
@@ -178,21 +281,53 @@ func (f *FlexpoolClient) MinerWorkers(coin string, address string) (workers []*W
 			result.Name,
 			result.IsOnline,
 			time.Unix(result.LastSteen, 0),
+			result.ReportedHashrate,
+			result.CurrentEffectiveHashrate,
+			result.AverageEffectiveHashrate,
+			result.ValidShares,
+			result.StaleShares,
+			result.InvalidShares,
 		)
 		workers = append(workers, worker)
 	}
 	return workers, nil
 }
 
+// WorkerStats returns current hashrates and shares of a worker
+// Flexpool serves worker stats on the miner stats route filtered by worker
+func (f *FlexpoolClient) WorkerStats(coin string, address string, worker string) (*MinerStats, error) {
+	body, err := f.request(fmt.Sprintf("%s/miner/stats?coin=%s&address=%s&worker=%s", FlexpoolAPIURL, coin, address, url.QueryEscape(worker)))
+	if err != nil {
+		return nil, err
+	}
+
+	var response MinerStatsResponse
+	json.Unmarshal(body, &response)
+	return NewMinerStats(
+		address,
+		response.Result.CurrentEffectiveHashrate,
+		response.Result.AverageEffectiveHashrate,
+		response.Result.ReportedHashrate,
+		response.Result.ValidShares,
+		response.Result.StaleShares,
+		response.Result.InvalidShares,
+	), nil
+}
+
 // BlocksResponse represents the JSON structure of the Flexpool API response for blocks
 type BlocksResponse struct {
 	Error  string `json:"error"`
 	Result struct {
 		TotalPages int `json:"totalPages"`
 		Data       []struct {
-			Hash   string  `json:"hash"`
-			Number uint64  `json:"number"`
-			Reward float64 `json:"reward"`
+			Hash      string  `json:"hash"`
+			Number    uint64  `json:"number"`
+			Type      string  `json:"type"`
+			Miner     string  `json:"miner"`
+			Reward    Amount  `json:"reward"`
+			Luck      float64 `json:"luck"`
+			Confirmed bool    `json:"confirmed"`
+			Timestamp int64   `json:"timestamp"`
 		} `json:"data"`
 	} `json:"result"`
 }
@@ -203,24 +338,16 @@ func (f *FlexpoolClient) PoolBlocks(coin string, limit int) (blocks []*Block, er
 	totalPages := 0
 
 	for page <= MaxIterations && len(blocks) < limit {
-		body, err := f.request(fmt.Sprintf("%s/pool/blocks/?coin=%s&page=%d", FlexpoolAPIURL, coin, page))
+		pageBlocks, pages, err := f.PoolBlocksPage(coin, page)
 		if err != nil {
 			return nil, err
 		}
 
-		var response BlocksResponse
-		json.Unmarshal(body, &response)
-
 		if totalPages == 0 {
-			totalPages = response.Result.TotalPages
+			totalPages = pages
 		}
 
-		for _, result := range response.Result.Data {
-			block := NewBlock(
-				result.Hash,
-				result.Number,
-				result.Reward,
-			)
+		for _, block := range pageBlocks {
 			blocks = append(blocks, block)
 			if len(blocks) >= limit {
 				break
@@ -242,6 +369,32 @@ func (f *FlexpoolClient) PoolBlocks(coin string, limit int) (blocks []*Block, er
 	return blocks, nil
 }
 
+// PoolBlocksPage returns blocks of a page, from the most recent, and the total number of pages
+func (f *FlexpoolClient) PoolBlocksPage(coin string, page int) (blocks []*Block, totalPages int, err error) {
+	body, err := f.request(fmt.Sprintf("%s/pool/blocks/?coin=%s&page=%d", FlexpoolAPIURL, coin, page))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response BlocksResponse
+	json.Unmarshal(body, &response)
+
+	for _, result := range response.Result.Data {
+		blocks = append(blocks, NewBlock(
+			coin,
+			result.Hash,
+			result.Number,
+			result.Type,
+			result.Miner,
+			result.Reward,
+			result.Luck,
+			result.Confirmed,
+			result.Timestamp,
+		))
+	}
+	return blocks, response.Result.TotalPages, nil
+}
+
 // LastPoolBlock return the last discovered block for a given pool
 func (f *FlexpoolClient) LastPoolBlock(pool *Pool) (*Block, error) {
 	blocks, err := f.PoolBlocks(pool.Coin, 1)
@@ -251,6 +404,55 @@ func (f *FlexpoolClient) LastPoolBlock(pool *Pool) (*Block, error) {
 	return blocks[0], nil
 }
 
+// PoolHashrateResponse represents the JSON structure of the Flexpool API response for pool hashrate
+type PoolHashrateResponse struct {
+	Error  string `json:"error"`
+	Result struct {
+		Total float64 `json:"total"`
+	} `json:"result"`
+}
+
+// PoolValueResponse represents the JSON structure of the Flexpool API response for single value pool routes
+type PoolValueResponse struct {
+	Error  string  `json:"error"`
+	Result float64 `json:"result"`
+}
+
+// poolValue returns the value of a single value pool route
+func (f *FlexpoolClient) poolValue(route string, coin string) (float64, error) {
+	body, err := f.request(fmt.Sprintf("%s/pool/%s?coin=%s", FlexpoolAPIURL, route, coin))
+	if err != nil {
+		return 0, err
+	}
+	var response PoolValueResponse
+	json.Unmarshal(body, &response)
+	return response.Result, nil
+}
+
+// PoolStats returns a pool with its hashrate, current effort, average luck and network difficulty
+func (f *FlexpoolClient) PoolStats(coin string) (pool *Pool, err error) {
+	pool = NewPool(coin)
+
+	body, err := f.request(fmt.Sprintf("%s/pool/hashrate?coin=%s", FlexpoolAPIURL, coin))
+	if err != nil {
+		return nil, err
+	}
+	var response PoolHashrateResponse
+	json.Unmarshal(body, &response)
+	pool.Hashrate = response.Result.Total
+
+	if pool.CurrentEffort, err = f.poolValue("currentLuck", coin); err != nil {
+		return nil, err
+	}
+	if pool.AverageLuck, err = f.poolValue("averageLuck", coin); err != nil {
+		return nil, err
+	}
+	if pool.NetworkDifficulty, err = f.poolValue("networkDifficulty", coin); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
 // CoinsResponse represents the JSON structure of the Flexpool API response for pool coins
 type CoinsResponse struct {
 	Error  string `json:"error"`
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Coin to store how a coin is converted, validated and displayed
+type Coin struct {
+	Name           string `yaml:"name"`
+	DisplayName    string `yaml:"display-name"`
+	Decimals       int    `yaml:"decimals"`
+	AddressPattern string `yaml:"address-pattern"`
+	AddressFormat  string `yaml:"address-format"`
+	BlockURL       string `yaml:"block-url"`
+	TransactionURL string `yaml:"transaction-url"`
+	PriceID        string `yaml:"price-id"`
+	addressRegexp  *regexp.Regexp
+}
+
+// String represents Coin to a printable format
+func (c *Coin) String() string {
+	return fmt.Sprintf("Coin<%s>", c.Name)
+}
+
+// Convert divides the smallest unit of the coin to the coin itself
+func (c *Coin) Convert(value Amount) *big.Float {
+	return value.Convert(c.Decimals)
+}
+
+// ParseUnits multiplies a value in units of the coin to its smallest unit
+func (c *Coin) ParseUnits(value float64) Amount {
+	return ParseUnits(value, c.Decimals)
+}
+
+// MatchAddress returns true when the address has the format of the coin
+func (c *Coin) MatchAddress(address string) bool {
+	return c.addressRegexp != nil && c.addressRegexp.MatchString(address)
+}
+
+// ValidateAddress verifies the address pattern and checksum
+func (c *Coin) ValidateAddress(address string) error {
+	if c.addressRegexp != nil && !c.addressRegexp.MatchString(address) {
+		return fmt.Errorf("Address does not match %s pattern %s", c.DisplayName, c.AddressPattern)
+	}
+	return ValidateAddress(c.AddressFormat, c.Name, address)
+}
+
+// FormatBlockURL returns the URL of a block on the explorer of the coin
+func (c *Coin) FormatBlockURL(hash string) (string, error) {
+	if c.BlockURL == "" {
+		return "", fmt.Errorf("No block URL for coin %s", c.Name)
+	}
+	return strings.ReplaceAll(c.BlockURL, "{hash}", hash), nil
+}
+
+// FormatTransactionURL returns the URL of a transaction on the explorer of the coin
+func (c *Coin) FormatTransactionURL(hash string) (string, error) {
+	if c.TransactionURL == "" {
+		return "", fmt.Errorf("No transaction URL for coin %s", c.Name)
+	}
+	return strings.ReplaceAll(c.TransactionURL, "{hash}", hash), nil
+}
+
+// merge overrides attributes of the coin with attributes set on the other coin
+func (c *Coin) merge(other Coin) {
+	if other.DisplayName != "" {
+		c.DisplayName = other.DisplayName
+	}
+	if other.Decimals != 0 {
+		c.Decimals = other.Decimals
+	}
+	if other.AddressPattern != "" {
+		c.AddressPattern = other.AddressPattern
+	}
+	if other.AddressFormat != "" {
+		c.AddressFormat = other.AddressFormat
+	}
+	if other.BlockURL != "" {
+		c.BlockURL = other.BlockURL
+	}
+	if other.TransactionURL != "" {
+		c.TransactionURL = other.TransactionURL
+	}
+	if other.PriceID != "" {
+		c.PriceID = other.PriceID
+	}
+}
+
+// CoinRegistry to store supported coins
+// Coins are ordered to deduce the coin of an address, the first match wins
+type CoinRegistry struct {
+	coins []*Coin
+}
+
+// NewCoinRegistry creates a CoinRegistry with the given coins
+func NewCoinRegistry(coins []Coin) *CoinRegistry {
+	registry := &CoinRegistry{}
+	for _, coin := range coins {
+		if err := registry.Register(coin); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// Register adds a coin to the registry or overrides attributes of a known coin
+func (r *CoinRegistry) Register(coin Coin) error {
+	coin.Name = strings.ToLower(coin.Name)
+	if coin.Name == "" {
+		return fmt.Errorf("Coin name is empty")
+	}
+
+	registered, err := r.Get(coin.Name)
+	known := err == nil
+	if !known {
+		// Decimals are merged only when set so a new coin must define them to convert amounts
+		if coin.Decimals == 0 {
+			return fmt.Errorf("Decimals of coin %s are not defined", coin.Name)
+		}
+		registered = &Coin{Name: coin.Name}
+	}
+	registered.merge(coin)
+
+	if registered.DisplayName == "" {
+		registered.DisplayName = strings.ToUpper(registered.Name)
+	}
+	if registered.AddressPattern != "" {
+		if registered.addressRegexp, err = regexp.Compile(registered.AddressPattern); err != nil {
+			return fmt.Errorf("Invalid address pattern for coin %s: %v", registered.Name, err)
+		}
+	}
+	if !known {
+		r.coins = append(r.coins, registered)
+	}
+	return nil
+}
+
+// Get returns a registered coin by name
+func (r *CoinRegistry) Get(name string) (*Coin, error) {
+	for _, coin := range r.coins {
+		if coin.Name == name {
+			return coin, nil
+		}
+	}
+	return nil, fmt.Errorf("Coin %s not supported", name)
+}
+
+// Deduce returns the first registered coin matching the address format
+func (r *CoinRegistry) Deduce(address string) (*Coin, error) {
+	for _, coin := range r.coins {
+		if coin.MatchAddress(address) {
+			return coin, nil
+		}
+	}
+	return nil, fmt.Errorf("Unsupported address")
+}
+
+// defaultCoins to store coins supported without configuration
+// ETH is declared before ETC because they share the same address format
+var defaultCoins = []Coin{
+	{
+		Name:           "eth",
+		DisplayName:    "Ethereum",
+		Decimals:       18,
+		AddressPattern: "^0x[0-9a-fA-F]{40}$",
+		AddressFormat:  AddressFormatEIP55,
+		BlockURL:       "https://etherscan.io/block/{hash}",
+		TransactionURL: "https://etherscan.io/tx/{hash}",
+		PriceID:        "ethereum",
+	},
+	{
+		Name:           "etc",
+		DisplayName:    "Ethereum Classic",
+		Decimals:       18,
+		AddressPattern: "^0x[0-9a-fA-F]{40}$",
+		AddressFormat:  AddressFormatEIP55,
+		BlockURL:       "https://etcblockexplorer.com/block/{hash}",
+		TransactionURL: "https://etcblockexplorer.com/tx/{hash}",
+		PriceID:        "ethereum-classic",
+	},
+	{
+		Name:           "xch",
+		DisplayName:    "Chia",
+		Decimals:       12,
+		AddressPattern: "^xch1[02-9ac-hj-np-z]{58}$",
+		AddressFormat:  AddressFormatBech32m,
+		BlockURL:       "https://www.chiaexplorer.com/blockchain/block/{hash}",
+		TransactionURL: "https://www.chiaexplorer.com/blockchain/coin/{hash}",
+		PriceID:        "chia",
+	},
+}
+
+// Coins to store the registry of supported coins, extended by the configuration
+var Coins = NewCoinRegistry(defaultCoins)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunCommand executes an on-demand command instead of sending notifications
+func RunCommand(name string, args []string, config *Config, db *gorm.DB, client *FlexpoolClient, prices PriceProvider) error {
+	assistant := NewAssistant(config, db, client, nil)
+	switch name {
+	case "eta":
+		return assistant.commandETA()
+	case "export":
+		return assistant.commandExport(args, prices)
+	case "backfill":
+		return assistant.commandBackfill(args)
+	default:
+		return fmt.Errorf("Unknown command (available: backfill, eta, export, migrate)")
+	}
+}
+
+// commandETA prints estimated daily earnings and payout ETA of all configured miners
+func (a *Assistant) commandETA() error {
+	for _, configuredMiner := range a.config.Miners {
+		miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
+		if err != nil {
+			return err
+		}
+		miner.Balance, err = a.client.MinerBalance(miner.Coin, miner.Address)
+		if err != nil {
+			return err
+		}
+		if err = a.estimatePayout(miner); err != nil {
+			fmt.Printf("%s: cannot estimate payout: %v\n", miner.Address, err)
+			continue
+		}
+
+		coin := strings.ToUpper(miner.Coin)
+		balance, _ := ConvertCurrency(miner.Coin, miner.Balance)
+		earnings, _ := ConvertCurrency(miner.Coin, miner.DailyEarnings)
+		threshold, _ := ConvertCurrency(miner.Coin, miner.PayoutLimit)
+		eta := "now"
+		if miner.PayoutETA > 0 {
+			eta = "in " + HumanizeDuration(miner.PayoutETA)
+		} else if miner.Balance.Cmp(miner.PayoutLimit) < 0 {
+			eta = "in more than " + HumanizeDuration(MaxPayoutETA)
+		}
+		fmt.Printf("%s: balance %.6f %s, earnings %.6f %s/day, payout at %.6f %s %s\n", miner.Address, balance, coin, earnings, coin, threshold, coin, eta)
+	}
+	return nil
+}
+
+// RunMigrateCommand shows, applies or reverts database migrations
+// Migrations are not applied automatically before this command
+func RunMigrateCommand(args []string, db *gorm.DB) error {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "status":
+		return commandMigrateStatus(db)
+	case "up":
+		if err := MigrateDatabase(db); err != nil {
+			return err
+		}
+		return commandMigrateStatus(db)
+	case "down":
+		if _, err := prepareMigrations(db); err != nil {
+			return err
+		}
+		migration, err := revertMigration(db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Migration %d reverted: %s\n", migration.Version, migration.Description)
+		return nil
+	default:
+		return fmt.Errorf("Unknown migrate action %s (available: status, up, down)", action)
+	}
+}
+
+// commandMigrateStatus prints applied and pending migrations
+func commandMigrateStatus(db *gorm.DB) error {
+	if _, err := prepareMigrations(db); err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		status := "pending"
+		if record, ok := applied[migration.Version]; ok {
+			status = "applied at " + record.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%d: %s (%s)\n", migration.Version, migration.Description, status)
+	}
+	return nil
+}
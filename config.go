@@ -1,36 +1,129 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
+	"math"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
 // Config to receive settings from the configuration file
 type Config struct {
-	DatabaseFile   string              `yaml:"database-file"`
-	MaxBlocks      int                 `yaml:"max-blocks"`
-	MaxPayments    int                 `yaml:"max-payments"`
-	Pools          []PoolConfig        `yaml:"pools"`
-	Miners         []MinerConfig       `yaml:"miners"`
-	TelegramConfig TelegramConfig      `yaml:"telegram"`
-	Notifications  NotificationsConfig `yaml:"notifications"`
+	DatabaseFile    string              `yaml:"database-file"`
+	Database        string              `yaml:"database"`
+	Interval        time.Duration       `yaml:"interval"`
+	WorkerRetention time.Duration       `yaml:"worker-retention"`
+	Retention       RetentionConfig     `yaml:"retention"`
+	MaxBlocks       int                 `yaml:"max-blocks"`
+	MaxPayments     int                 `yaml:"max-payments"`
+	Coins           []Coin              `yaml:"coins"`
+	Pools           []PoolConfig        `yaml:"pools"`
+	Miners          []MinerConfig       `yaml:"miners"`
+	Reports         []ReportConfig      `yaml:"reports"`
+	Prices          PricesConfig        `yaml:"prices"`
+	TelegramConfig  TelegramConfig      `yaml:"telegram"`
+	Notifications   NotificationsConfig `yaml:"notifications"`
 }
 
 // PoolConfig to store Pool configuration
 type PoolConfig struct {
-	Coin           string  `yaml:"coin"`
-	EnableBlocks   bool    `yaml:"enable-blocks"`
-	MinBlockReward float64 `yaml:"min-block-reward"`
+	Coin           string           `yaml:"coin"`
+	EnableStats    bool             `yaml:"enable-stats"`
+	StatsAlerts    PoolAlertsConfig `yaml:"stats-alerts"`
+	EnableBlocks   bool             `yaml:"enable-blocks"`
+	MinBlockReward float64          `yaml:"min-block-reward"`
+}
+
+// PoolAlertsConfig to store pool statistics alerts configuration
+type PoolAlertsConfig struct {
+	MaxEffort            float64 `yaml:"max-effort"`
+	MinAverageLuck       float64 `yaml:"min-average-luck"`
+	MinHashrate          float64 `yaml:"min-hashrate"`
+	MaxNetworkDifficulty float64 `yaml:"max-network-difficulty"`
 }
 
 // MinerConfig to store Miner configuration
 type MinerConfig struct {
-	Address              string `yaml:"address"`
-	Coin                 string `yaml:"coin"`
-	EnableBalance        bool   `yaml:"enable-balance"`
-	EnablePayments       bool   `yaml:"enable-payments"`
-	EnableOfflineWorkers bool   `yaml:"enable-offline-workers"`
+	Address              string               `yaml:"address"`
+	Coin                 string               `yaml:"coin"`
+	EnableStats          bool                 `yaml:"enable-stats"`
+	EnableBalance        bool                 `yaml:"enable-balance"`
+	EnablePayments       bool                 `yaml:"enable-payments"`
+	EnablePayoutSettings bool                 `yaml:"enable-payout-settings"`
+	EnableOfflineWorkers bool                 `yaml:"enable-offline-workers"`
+	BalanceAlerts        BalanceAlertsConfig  `yaml:"balance-alerts"`
+	Workers              WorkersConfig        `yaml:"workers"`
+	OfflineAlerts        OfflineAlertsConfig  `yaml:"offline-alerts"`
+	HashrateAlerts       HashrateAlertsConfig `yaml:"hashrate-alerts"`
+	ShareAlerts          ShareAlertsConfig    `yaml:"share-alerts"`
+}
+
+// BalanceAlertsConfig to store balance alerts configuration of a miner
+type BalanceAlertsConfig struct {
+	Thresholds   []float64     `yaml:"thresholds"`
+	MinIncrease  float64       `yaml:"min-increase"`
+	StalledAfter time.Duration `yaml:"stalled-after"`
+}
+
+// WorkersConfig to store expected and ignored workers of a miner
+// Names can be glob patterns (ex: "rig-*")
+type WorkersConfig struct {
+	Expected []string `yaml:"expected"`
+	Ignored  []string `yaml:"ignored"`
+}
+
+// OfflineAlertsConfig to store offline workers alerts configuration of a miner
+type OfflineAlertsConfig struct {
+	GracePeriod   time.Duration `yaml:"grace-period"`
+	MinOnline     time.Duration `yaml:"min-online"`
+	FlapThreshold int           `yaml:"flap-threshold"`
+	FlapWindow    time.Duration `yaml:"flap-window"`
+}
+
+// HashrateAlertsConfig to store hashrate alerts configuration of a miner
+type HashrateAlertsConfig struct {
+	MaxDrop float64       `yaml:"max-drop"`
+	Window  time.Duration `yaml:"window"`
+}
+
+// ShareAlertsConfig to store stale and invalid shares alerts configuration of a miner
+type ShareAlertsConfig struct {
+	MaxStaleRatio   float64       `yaml:"max-stale-ratio"`
+	MaxInvalidRatio float64       `yaml:"max-invalid-ratio"`
+	Window          time.Duration `yaml:"window"`
+}
+
+// ReportConfig to store scheduled report configuration
+type ReportConfig struct {
+	Name     string `yaml:"name"`
+	Schedule string `yaml:"schedule"`
+}
+
+// RetentionConfig to store retention policies of history tables
+// A zero duration keeps data forever or disables the feature
+type RetentionConfig struct {
+	Workers        time.Duration `yaml:"workers"`
+	BalanceHistory time.Duration `yaml:"balance-history"`
+	WorkerHistory  time.Duration `yaml:"worker-history"`
+	MinerStats     time.Duration `yaml:"miner-stats"`
+	Payments       time.Duration `yaml:"payments"`
+	Blocks         time.Duration `yaml:"blocks"`
+	HourlyAfter    time.Duration `yaml:"hourly-after"`
+	DailyAfter     time.Duration `yaml:"daily-after"`
+	Interval       time.Duration `yaml:"interval"`
+	VacuumInterval time.Duration `yaml:"vacuum-interval"`
+}
+
+// PricesConfig to store fiat conversion configuration
+type PricesConfig struct {
+	Currency string             `yaml:"currency"`
+	Provider string             `yaml:"provider"`
+	URL      string             `yaml:"url"`
+	Cache    time.Duration      `yaml:"cache"`
+	Static   map[string]float64 `yaml:"static"`
 }
 
 // TelegramConfig to store Telegram configuration
@@ -42,10 +135,21 @@ type TelegramConfig struct {
 
 // NotificationTemplatesConfig to store all notifications configurations
 type NotificationsConfig struct {
-	Balance       NotificationConfig `yaml:"balance"`
-	Payment       NotificationConfig `yaml:"payment"`
-	Block         NotificationConfig `yaml:"block"`
-	OfflineWorker NotificationConfig `yaml:"offline-worker"`
+	Balance        NotificationConfig `yaml:"balance"`
+	BalanceAlert   NotificationConfig `yaml:"balance-alert"`
+	Payment        NotificationConfig `yaml:"payment"`
+	PayoutSettings NotificationConfig `yaml:"payout-settings"`
+	Block          NotificationConfig `yaml:"block"`
+	MinerBlock     NotificationConfig `yaml:"miner-block"`
+	BlockStatus    NotificationConfig `yaml:"block-status"`
+	PoolStats      NotificationConfig `yaml:"pool-stats"`
+	OfflineWorker  NotificationConfig `yaml:"offline-worker"`
+	FlappingWorker NotificationConfig `yaml:"flapping-worker"`
+	MissingWorker  NotificationConfig `yaml:"missing-worker"`
+	HashrateDrop   NotificationConfig `yaml:"hashrate-drop"`
+	ShareRatio     NotificationConfig `yaml:"share-ratio"`
+	Report         NotificationConfig `yaml:"report"`
+	Digest         DigestConfig       `yaml:"digest"`
 }
 
 // NotificationConfig to store a single notification configuration
@@ -54,11 +158,69 @@ type NotificationConfig struct {
 	Test     bool   `yaml:"test"`
 }
 
+// DigestConfig to store digest notification configuration
+type DigestConfig struct {
+	Enable   bool          `yaml:"enable"`
+	Window   time.Duration `yaml:"window"`
+	Template string        `yaml:"template"`
+}
+
 // NewConfig creates a Config with default values
 func NewConfig() *Config {
 	return &Config{
 		DatabaseFile: AppName + ".db",
+		Retention: RetentionConfig{
+			Workers:        WorkerRetention,
+			BalanceHistory: BalanceHistoryRetention,
+			WorkerHistory:  WorkerHistoryRetention,
+			MinerStats:     MinerStatsRetention,
+			HourlyAfter:    HourlyDownsamplingAfter,
+			DailyAfter:     DailyDownsamplingAfter,
+			Interval:       RetentionInterval,
+			VacuumInterval: VacuumInterval,
+		},
+	}
+}
+
+// DatabaseDSN returns the DSN of the database, the SQLite database file is used by default
+func (c *Config) DatabaseDSN() string {
+	if c.Database != "" {
+		return c.Database
 	}
+	return "sqlite://" + c.DatabaseFile
+}
+
+// Validate verifies addresses and balance alerts of configured miners
+func (c *Config) Validate() error {
+	for i, configuredMiner := range c.Miners {
+		var coin *Coin
+		var err error
+		if configuredMiner.Coin == "" {
+			coin, err = Coins.Deduce(configuredMiner.Address)
+		} else {
+			coin, err = Coins.Get(configuredMiner.Coin)
+		}
+		if err == nil {
+			err = coin.ValidateAddress(configuredMiner.Address)
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid address %q in miners entry #%d: %v", configuredMiner.Address, i+1, err)
+		}
+
+		// Amounts are converted to the smallest unit of the coin on every run
+		for _, threshold := range configuredMiner.BalanceAlerts.Thresholds {
+			if !(threshold > 0) || math.IsInf(threshold, 0) {
+				return fmt.Errorf("Invalid balance threshold %v in miners entry #%d: must be a positive number", threshold, i+1)
+			}
+			if _, err = ParseCurrency(coin.Name, threshold); err != nil {
+				return fmt.Errorf("Invalid balance threshold %v in miners entry #%d: %v", threshold, i+1, err)
+			}
+		}
+		if minIncrease := configuredMiner.BalanceAlerts.MinIncrease; !(minIncrease >= 0) || math.IsInf(minIncrease, 0) {
+			return fmt.Errorf("Invalid minimum balance increase %v in miners entry #%d: must be a positive number", minIncrease, i+1)
+		}
+	}
+	return nil
 }
 
 // ReadFile reads and parses a YAML configuration file to override default values
@@ -73,5 +235,13 @@ func (c *Config) ReadFile(filename string) (err error) {
 		return err
 	}
 
+	// Deprecated worker-retention is used unless retention.workers has been changed
+	if c.WorkerRetention > 0 {
+		log.Warn("worker-retention is deprecated, use retention.workers instead")
+		if c.Retention.Workers == WorkerRetention {
+			c.Retention.Workers = c.WorkerRetention
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		alerts BalanceAlertsConfig
+		valid  bool
+	}{
+		{"no alerts", BalanceAlertsConfig{}, true},
+		{"thresholds", BalanceAlertsConfig{Thresholds: []float64{0.05, 1}, MinIncrease: 0.01}, true},
+		{"zero threshold", BalanceAlertsConfig{Thresholds: []float64{0.05, 0}}, false},
+		{"negative threshold", BalanceAlertsConfig{Thresholds: []float64{-1}}, false},
+		{"infinite threshold", BalanceAlertsConfig{Thresholds: []float64{math.Inf(1)}}, false},
+		{"not a number threshold", BalanceAlertsConfig{Thresholds: []float64{math.NaN()}}, false},
+		{"negative minimum increase", BalanceAlertsConfig{MinIncrease: -0.01}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			config.Miners = []MinerConfig{{Address: testAddress, Coin: "eth", BalanceAlerts: tc.alerts}}
+			err := config.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid configuration, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid configuration")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// estimateDailyEarnings returns the earnings per day of a miner in the smallest unit of the coin
+// The balance history is used first, then the estimation of the pool when the history is too short
+func (a *Assistant) estimateDailyEarnings(miner *Miner) (Amount, error) {
+	var records []BalanceRecord
+	trx := a.db.Where("miner_address = ? AND created_at >= ?", miner.Address, time.Now().Add(-EarningsWindow)).Order("created_at").Find(&records)
+	if trx.Error != nil {
+		return Amount{}, trx.Error
+	}
+
+	if len(records) >= 2 {
+		span := records[len(records)-1].CreatedAt.Sub(records[0].CreatedAt)
+		if span >= MinEarningsSpan {
+			earned := NewAmount(0)
+			for i := 1; i < len(records); i++ {
+				// Ignore payouts
+				if increase := records[i].Value.Sub(records[i-1].Value); increase.Sign() > 0 {
+					earned = earned.Add(increase)
+				}
+			}
+			return earned.Mul(int64(24 * time.Hour)).Div(int64(span)), nil
+		}
+	}
+
+	log.Debugf("Not enough balance history for %s, using the pool estimation", miner)
+	return a.client.MinerEstimatedDailyRevenue(miner.Coin, miner.Address)
+}
+
+// estimatePayout estimates daily earnings of a miner and the remaining time before its balance reaches the payout
+// threshold
+func (a *Assistant) estimatePayout(miner *Miner) (err error) {
+	miner.DailyEarnings, err = a.estimateDailyEarnings(miner)
+	if err != nil {
+		return err
+	}
+
+	details, err := a.client.MinerDetails(miner.Coin, miner.Address)
+	if err != nil {
+		return err
+	}
+	miner.PayoutLimit = details.PayoutLimit
+
+	if miner.DailyEarnings.Sign() <= 0 {
+		return fmt.Errorf("No earnings")
+	}
+	if miner.Balance.Cmp(miner.PayoutLimit) < 0 {
+		// Float precision is enough for a duration
+		days := miner.PayoutLimit.Sub(miner.Balance).Float64() / miner.DailyEarnings.Float64()
+		// Tiny earnings would overflow the duration
+		if days > MaxPayoutETA.Hours()/24 {
+			log.Debugf("Payout of %s is more than %s away", miner, HumanizeDuration(MaxPayoutETA))
+			return nil
+		}
+		miner.PayoutETA = time.Duration(days * float64(24*time.Hour))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEstimatePayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  int64
+		limit    int64
+		earnings int64
+		expected time.Duration
+		err      bool
+	}{
+		{"one day", 0, 100000000000000000, 100000000000000000, 24 * time.Hour, false},
+		{"half a day", 50000000000000000, 100000000000000000, 100000000000000000, 12 * time.Hour, false},
+		{"limit reached", 200000000000000000, 100000000000000000, 100000000000000000, 0, false},
+		{"tiny earnings", 0, 100000000000000000, 1, 0, false},
+		{"no earnings", 0, 100000000000000000, 0, 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/miner/details":
+					fmt.Fprintf(w, `{"error": null, "result": {"payoutLimit": %d}}`, tc.limit)
+				case "/v2/miner/estimatedDailyRevenue":
+					fmt.Fprintf(w, `{"error": null, "result": %d}`, tc.earnings)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+			assistant := NewAssistant(NewConfig(), db, client, nil)
+
+			miner := &Miner{Address: testAddress, Coin: "eth", Balance: NewAmount(tc.balance)}
+			err := assistant.estimatePayout(miner)
+			if tc.err {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if miner.PayoutETA != tc.expected {
+				t.Errorf("Expected ETA of %s, got %s", tc.expected, miner.PayoutETA)
+			}
+		})
+	}
+}
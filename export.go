@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ExportFormatCSV to export records as comma separated values with a header
+const ExportFormatCSV = "csv"
+
+// ExportFormatJSON to export records as a JSON array
+const ExportFormatJSON = "json"
+
+// ExportFormatJSONLines to export records as one JSON object per line
+const ExportFormatJSONLines = "jsonl"
+
+// ExportTable to store exported records as ordered columns and values
+type ExportTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ExportFilter to store the miner and the period of exported records
+// Zero values disable the filter, the end of the period is excluded
+type ExportFilter struct {
+	Miner string
+	From  time.Time
+	To    time.Time
+}
+
+// exporters to store functions exporting each kind of records
+var exporters = map[string]func(a *Assistant, filter *ExportFilter, prices PriceProvider) (*ExportTable, error){
+	"payments": (*Assistant).exportPayments,
+	"balances": (*Assistant).exportBalances,
+	"blocks":   (*Assistant).exportBlocks,
+	"workers":  (*Assistant).exportWorkers,
+}
+
+// commandExport writes payments, balance history, blocks or worker events to a file or the standard output
+func (a *Assistant) commandExport(args []string, prices PriceProvider) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	miner := flags.String("miner", "", "Export records of this miner address only")
+	from := flags.String("from", "", "Export records from this date (ex: 2021-01-01 or 2021-01-01T00:00:00Z)")
+	to := flags.String("to", "", "Export records before this date (ex: 2022-01-01 or 2022-01-01T00:00:00Z)")
+	format := flags.String("format", ExportFormatCSV, "Output format (csv, json or jsonl)")
+	output := flags.String("output", "", "Output file name (standard output by default)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Export command requires one kind of records (available: payments, balances, blocks, workers)")
+	}
+	kind := flags.Arg(0)
+	exporter, ok := exporters[kind]
+	if !ok {
+		return fmt.Errorf("Unknown kind of records %s (available: payments, balances, blocks, workers)", kind)
+	}
+
+	filter := &ExportFilter{Miner: *miner}
+	var err error
+	if filter.From, err = parseCommandDate(*from); err != nil {
+		return err
+	}
+	if filter.To, err = parseCommandDate(*to); err != nil {
+		return err
+	}
+
+	table, err := exporter(a, filter, prices)
+	if err != nil {
+		return err
+	}
+
+	writer := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	switch *format {
+	case ExportFormatCSV:
+		err = table.WriteCSV(writer)
+	case ExportFormatJSON:
+		err = table.WriteJSON(writer)
+	case ExportFormatJSONLines:
+		err = table.WriteJSONLines(writer)
+	default:
+		return fmt.Errorf("Unknown export format %s (available: %s, %s, %s)", *format, ExportFormatCSV, ExportFormatJSON, ExportFormatJSONLines)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Exported %d %s records", len(table.Rows), kind)
+	return nil
+}
+
+// parseCommandDate parses a date or a RFC3339 time given to a command, dates are in local time
+func parseCommandDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid date %q (ex: 2021-01-01 or 2021-01-01T00:00:00Z)", value)
+	}
+	return date, nil
+}
+
+// filterTime returns a query filtered by miner and period on a time column
+func (f *ExportFilter) filterTime(query *gorm.DB, minerColumn string, timeColumn string) *gorm.DB {
+	if f.Miner != "" {
+		query = query.Where(minerColumn+" = ?", f.Miner)
+	}
+	if !f.From.IsZero() {
+		query = query.Where(timeColumn+" >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where(timeColumn+" < ?", f.To)
+	}
+	return query.Order(timeColumn)
+}
+
+// filterTimestamp returns a query filtered by miner and period on a unix timestamp column
+func (f *ExportFilter) filterTimestamp(query *gorm.DB, minerColumn string, timestampColumn string) *gorm.DB {
+	if f.Miner != "" {
+		query = query.Where(minerColumn+" = ?", f.Miner)
+	}
+	if !f.From.IsZero() {
+		query = query.Where(timestampColumn+" >= ?", f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		query = query.Where(timestampColumn+" < ?", f.To.Unix())
+	}
+	return query.Order(timestampColumn)
+}
+
+// minerCoins returns the coin of each known miner by address
+func (a *Assistant) minerCoins() (map[string]*Coin, error) {
+	var miners []Miner
+	if trx := a.db.Find(&miners); trx.Error != nil {
+		return nil, trx.Error
+	}
+	coins := make(map[string]*Coin)
+	for _, miner := range miners {
+		coin, err := Coins.Get(miner.Coin)
+		if err != nil {
+			log.Warnf("Cannot export amounts of %s: %v", miner.Address, err)
+			continue
+		}
+		coins[miner.Address] = coin
+	}
+	return coins, nil
+}
+
+// minerCoin returns the coin of a miner from the database or deduced from its address
+func minerCoin(coins map[string]*Coin, address string) (*Coin, error) {
+	if coin, ok := coins[address]; ok {
+		return coin, nil
+	}
+	coin, err := Coins.Deduce(address)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot find coin of miner %s: %v", address, err)
+	}
+	coins[address] = coin
+	return coin, nil
+}
+
+// exportPayments exports payments with their fiat value at the time of the payment when prices are configured
+func (a *Assistant) exportPayments(filter *ExportFilter, prices PriceProvider) (*ExportTable, error) {
+	var payments []Payment
+	if trx := filter.filterTimestamp(a.db, "miner_address", "timestamp").Find(&payments); trx.Error != nil {
+		return nil, trx.Error
+	}
+	coins, err := a.minerCoins()
+	if err != nil {
+		return nil, err
+	}
+
+	historical, _ := prices.(HistoricalPriceProvider)
+	if prices != nil && historical == nil {
+		log.Warn("Price provider does not support historical prices, fiat values are not exported")
+	}
+	currency := strings.ToUpper(a.config.Prices.Currency)
+
+	table := &ExportTable{Columns: []string{"timestamp", "miner", "coin", "hash", "value", "raw_value", "fee", "raw_fee", "confirmed", "fiat_value", "fiat_currency"}}
+	for _, payment := range payments {
+		coin, err := minerCoin(coins, payment.MinerAddress)
+		if err != nil {
+			return nil, err
+		}
+		date := time.Unix(payment.Timestamp, 0)
+
+		var fiatValue, fiatCurrency interface{}
+		if historical != nil {
+			price, err := historical.PriceAt(coin.Name, currency, date)
+			if err != nil {
+				log.Warnf("Cannot fetch price of payment %s: %v", payment.Hash, err)
+			} else {
+				fiatValue, _ = new(big.Float).Mul(coin.Convert(payment.Value), big.NewFloat(price)).Float64()
+				fiatCurrency = currency
+			}
+		}
+
+		table.Rows = append(table.Rows, []interface{}{
+			date, payment.MinerAddress, coin.Name, payment.Hash,
+			payment.Value.FormatUnits(coin.Decimals), payment.Value,
+			payment.Fee.FormatUnits(coin.Decimals), payment.Fee,
+			payment.Confirmed, fiatValue, fiatCurrency,
+		})
+	}
+	return table, nil
+}
+
+// exportBalances exports the balance history
+func (a *Assistant) exportBalances(filter *ExportFilter, prices PriceProvider) (*ExportTable, error) {
+	var records []BalanceRecord
+	if trx := filter.filterTime(a.db, "miner_address", "created_at").Find(&records); trx.Error != nil {
+		return nil, trx.Error
+	}
+	coins, err := a.minerCoins()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &ExportTable{Columns: []string{"timestamp", "miner", "coin", "balance", "raw_balance"}}
+	for _, record := range records {
+		coin, err := minerCoin(coins, record.MinerAddress)
+		if err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			record.CreatedAt, record.MinerAddress, coin.Name, record.Value.FormatUnits(coin.Decimals), record.Value,
+		})
+	}
+	return table, nil
+}
+
+// exportBlocks exports blocks found by the pools, filtered by the miner who found them
+func (a *Assistant) exportBlocks(filter *ExportFilter, prices PriceProvider) (*ExportTable, error) {
+	var blocks []Block
+	if trx := filter.filterTimestamp(a.db, "miner_address", "timestamp").Find(&blocks); trx.Error != nil {
+		return nil, trx.Error
+	}
+
+	table := &ExportTable{Columns: []string{"timestamp", "coin", "number", "hash", "type", "miner", "reward", "raw_reward", "confirmed"}}
+	for _, block := range blocks {
+		coin, err := Coins.Get(block.Coin)
+		if err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			time.Unix(block.Timestamp, 0), block.Coin, block.Number, block.Hash, block.Type, block.MinerAddress,
+			block.Reward.FormatUnits(coin.Decimals), block.Reward, block.Confirmed,
+		})
+	}
+	return table, nil
+}
+
+// exportWorkers exports observed states of workers
+func (a *Assistant) exportWorkers(filter *ExportFilter, prices PriceProvider) (*ExportTable, error) {
+	var records []WorkerRecord
+	if trx := filter.filterTime(a.db, "miner_address", "created_at").Find(&records); trx.Error != nil {
+		return nil, trx.Error
+	}
+
+	table := &ExportTable{Columns: []string{"timestamp", "miner", "worker", "online", "reported_hashrate", "effective_hashrate", "valid_shares", "stale_shares", "invalid_shares"}}
+	for _, record := range records {
+		table.Rows = append(table.Rows, []interface{}{
+			record.CreatedAt, record.MinerAddress, record.Name, record.IsOnline, record.ReportedHashrate,
+			record.EffectiveHashrate, record.ValidShares, record.StaleShares, record.InvalidShares,
+		})
+	}
+	return table, nil
+}
+
+// WriteCSV writes the table as comma separated values with a header
+func (t *ExportTable) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+	if err := w.Write(t.Columns); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatExportValue(value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// WriteJSON writes the table as a JSON array of objects
+func (t *ExportTable) WriteJSON(writer io.Writer) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+	for i := range t.Rows {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n  ")
+		object, err := t.marshalRow(i)
+		if err != nil {
+			return err
+		}
+		buffer.Write(object)
+	}
+	buffer.WriteString("\n]\n")
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// WriteJSONLines writes the table as one JSON object per line
+func (t *ExportTable) WriteJSONLines(writer io.Writer) error {
+	for i := range t.Rows {
+		object, err := t.marshalRow(i)
+		if err != nil {
+			return err
+		}
+		if _, err = writer.Write(append(object, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalRow encodes a row as a JSON object with keys in the order of columns
+// Amounts are numbers and values in units of coins are strings to keep them exact
+func (t *ExportTable) marshalRow(index int) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, value := range t.Rows[index] {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		key, _ := json.Marshal(t.Columns[i])
+		buffer.Write(key)
+		buffer.WriteString(":")
+		if date, ok := value.(time.Time); ok {
+			value = date.Format(time.RFC3339)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(encoded)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// formatExportValue represents a value in a CSV file
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeHistoricalPrices returns a fixed price at any date
+type fakeHistoricalPrices struct {
+	price float64
+}
+
+func (f *fakeHistoricalPrices) Price(coin string, currency string) (float64, error) {
+	return f.price, nil
+}
+
+func (f *fakeHistoricalPrices) PriceAt(coin string, currency string, date time.Time) (float64, error) {
+	return f.price, nil
+}
+
+func TestExportTableFormats(t *testing.T) {
+	date := time.Date(2021, 9, 5, 10, 0, 0, 0, time.UTC)
+	raw, err := ParseAmount("1234567890123456789012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &ExportTable{
+		Columns: []string{"timestamp", "hash", "value", "raw_value", "confirmed", "fiat_value"},
+		Rows: [][]interface{}{
+			{date, "0x1", "1234.567890123456789012", raw, true, 1.5},
+			{date, "0x2", "0", Amount{}, false, nil},
+		},
+	}
+	tests := []struct {
+		format   string
+		write    func(t *ExportTable, buffer *bytes.Buffer) error
+		expected string
+	}{
+		{
+			format: ExportFormatCSV,
+			write:  func(t *ExportTable, buffer *bytes.Buffer) error { return t.WriteCSV(buffer) },
+			expected: "timestamp,hash,value,raw_value,confirmed,fiat_value\n" +
+				"2021-09-05T10:00:00Z,0x1,1234.567890123456789012,1234567890123456789012,true,1.5\n" +
+				"2021-09-05T10:00:00Z,0x2,0,0,false,\n",
+		},
+		{
+			format: ExportFormatJSON,
+			write:  func(t *ExportTable, buffer *bytes.Buffer) error { return t.WriteJSON(buffer) },
+			expected: "[\n" +
+				`  {"timestamp":"2021-09-05T10:00:00Z","hash":"0x1","value":"1234.567890123456789012","raw_value":1234567890123456789012,"confirmed":true,"fiat_value":1.5},` + "\n" +
+				`  {"timestamp":"2021-09-05T10:00:00Z","hash":"0x2","value":"0","raw_value":0,"confirmed":false,"fiat_value":null}` + "\n" +
+				"]\n",
+		},
+		{
+			format: ExportFormatJSONLines,
+			write:  func(t *ExportTable, buffer *bytes.Buffer) error { return t.WriteJSONLines(buffer) },
+			expected: `{"timestamp":"2021-09-05T10:00:00Z","hash":"0x1","value":"1234.567890123456789012","raw_value":1234567890123456789012,"confirmed":true,"fiat_value":1.5}` + "\n" +
+				`{"timestamp":"2021-09-05T10:00:00Z","hash":"0x2","value":"0","raw_value":0,"confirmed":false,"fiat_value":null}` + "\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := tc.write(table, &buffer); err != nil {
+				t.Fatalf("Cannot write table: %v", err)
+			}
+			if buffer.String() != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, buffer.String())
+			}
+		})
+	}
+}
+
+func TestExportPayments(t *testing.T) {
+	db := newTestDatabase(t)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+	other := "0x0000000000000000000000000000000000000002"
+	start := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i, address := range []string{testAddress, testAddress, testAddress, other} {
+		timestamp := start.Add(time.Duration(i) * 24 * time.Hour).Unix()
+		payment := NewPayment(address, fmt.Sprintf("0x%d", i), NewAmount(int64(i+1)*100000000000000000), NewAmount(1000000000000000), timestamp, true)
+		if trx := db.Create(payment); trx.Error != nil {
+			t.Fatal(trx.Error)
+		}
+	}
+	config := NewConfig()
+	config.Prices.Currency = "usd"
+	assistant := NewAssistant(config, db, nil, nil)
+
+	tests := []struct {
+		name     string
+		filter   ExportFilter
+		expected []string
+	}{
+		{"all", ExportFilter{}, []string{"0x0", "0x1", "0x2", "0x3"}},
+		{"miner", ExportFilter{Miner: testAddress}, []string{"0x0", "0x1", "0x2"}},
+		{"from", ExportFilter{From: start.Add(24 * time.Hour)}, []string{"0x1", "0x2", "0x3"}},
+		// The end of the period is excluded
+		{"period", ExportFilter{From: start.Add(24 * time.Hour), To: start.Add(48 * time.Hour)}, []string{"0x1"}},
+		{"unknown miner", ExportFilter{Miner: "0x0000000000000000000000000000000000000003"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			table, err := assistant.exportPayments(&tc.filter, &fakeHistoricalPrices{price: 2000})
+			if err != nil {
+				t.Fatalf("Cannot export payments: %v", err)
+			}
+			if len(table.Rows) != len(tc.expected) {
+				t.Fatalf("Expected %d payment(s), got %d", len(tc.expected), len(table.Rows))
+			}
+			for i, hash := range tc.expected {
+				row := table.Rows[i]
+				if row[3] != hash {
+					t.Errorf("Expected payment %s at row %d, got %v", hash, i, row[3])
+				}
+				// Coins of miners unknown to the database are deduced from their address
+				if row[2] != "eth" {
+					t.Errorf("Expected eth coin, got %v", row[2])
+				}
+				var index int
+				fmt.Sscanf(hash, "0x%d", &index)
+				if expected := float64((index + 1) * 200); row[9] != expected || row[10] != "USD" {
+					t.Errorf("Expected fiat value of %v USD, got %v %v", expected, row[9], row[10])
+				}
+			}
+		})
+	}
+}
+
+func TestCommandExport(t *testing.T) {
+	db := newTestDatabase(t)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+	records := []WorkerRecord{
+		{MinerAddress: testAddress, Name: "rig-01", IsOnline: true, EffectiveHashrate: 100, CreatedAt: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{MinerAddress: testAddress, Name: "rig-01", IsOnline: false, CreatedAt: time.Date(2021, 9, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	if trx := db.Create(&records); trx.Error != nil {
+		t.Fatal(trx.Error)
+	}
+	assistant := NewAssistant(NewConfig(), db, nil, nil)
+
+	output := filepath.Join(t.TempDir(), "workers.jsonl")
+	if err := assistant.commandExport([]string{"-format", "jsonl", "-output", output, "-to", "2021-09-02T00:00:00Z", "workers"}, nil); err != nil {
+		t.Fatalf("Cannot export workers: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"timestamp":"2021-09-01T00:00:00Z","miner":"` + testAddress + `","worker":"rig-01","online":true,"reported_hashrate":0,"effective_hashrate":100,"valid_shares":0,"stale_shares":0,"invalid_shares":0}` + "\n"
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	for _, args := range [][]string{{}, {"unknown"}, {"-format", "xml", "workers"}, {"-from", "yesterday", "workers"}} {
+		if err := assistant.commandExport(args, nil); err == nil {
+			t.Errorf("Expected export with %v to fail", args)
+		}
+	}
+}
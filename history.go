@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// BalanceRecord to store an observed unpaid balance of a miner
+type BalanceRecord struct {
+	ID           uint      `gorm:"primarykey"`
+	MinerAddress string    `gorm:"size:255;index;not null"`
+	Value        Amount    `gorm:"not null;default:0"`
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+// NewBalanceRecord creates a BalanceRecord
+func NewBalanceRecord(minerAddress string, value Amount) *BalanceRecord {
+	return &BalanceRecord{
+		MinerAddress: minerAddress,
+		Value:        value,
+	}
+}
+
+// String represents BalanceRecord to a printable format
+func (b *BalanceRecord) String() string {
+	return fmt.Sprintf("BalanceRecord<%s, %s>", b.MinerAddress, b.Value)
+}
+
+// WorkerRecord to store an observed state of a worker
+type WorkerRecord struct {
+	ID                uint      `gorm:"primarykey"`
+	MinerAddress      string    `gorm:"size:255;index;not null"`
+	Name              string    `gorm:"not null"`
+	IsOnline          bool      `gorm:"not null"`
+	ReportedHashrate  float64   `gorm:"not null;default:0"`
+	EffectiveHashrate float64   `gorm:"not null"`
+	ValidShares       uint64    `gorm:"not null;default:0"`
+	StaleShares       uint64    `gorm:"not null;default:0"`
+	InvalidShares     uint64    `gorm:"not null;default:0"`
+	CreatedAt         time.Time `gorm:"index"`
+}
+
+// NewWorkerRecord creates a WorkerRecord from a Worker
+func NewWorkerRecord(worker *Worker) *WorkerRecord {
+	return &WorkerRecord{
+		MinerAddress:      worker.MinerAddress,
+		Name:              worker.Name,
+		IsOnline:          worker.IsOnline,
+		ReportedHashrate:  worker.ReportedHashrate,
+		EffectiveHashrate: worker.EffectiveHashrate,
+		ValidShares:       worker.ValidShares,
+		StaleShares:       worker.StaleShares,
+		InvalidShares:     worker.InvalidShares,
+	}
+}
+
+// String represents WorkerRecord to a printable format
+func (w *WorkerRecord) String() string {
+	return fmt.Sprintf("WorkerRecord<%s>", w.Name)
+}
+
+// MinerStats to store observed hashrates and shares of a miner
+type MinerStats struct {
+	ID                       uint      `gorm:"primarykey"`
+	MinerAddress             string    `gorm:"size:255;index;not null"`
+	EffectiveHashrate        float64   `gorm:"not null"`
+	AverageEffectiveHashrate float64   `gorm:"not null"`
+	ReportedHashrate         float64   `gorm:"not null"`
+	ValidShares              uint64    `gorm:"not null"`
+	StaleShares              uint64    `gorm:"not null"`
+	InvalidShares            uint64    `gorm:"not null"`
+	CreatedAt                time.Time `gorm:"index"`
+}
+
+// NewMinerStats creates a MinerStats
+func NewMinerStats(minerAddress string, effectiveHashrate float64, averageEffectiveHashrate float64, reportedHashrate float64, validShares uint64, staleShares uint64, invalidShares uint64) *MinerStats {
+	return &MinerStats{
+		MinerAddress:             minerAddress,
+		EffectiveHashrate:        effectiveHashrate,
+		AverageEffectiveHashrate: averageEffectiveHashrate,
+		ReportedHashrate:         reportedHashrate,
+		ValidShares:              validShares,
+		StaleShares:              staleShares,
+		InvalidShares:            invalidShares,
+	}
+}
+
+// String represents MinerStats to a printable format
+func (m *MinerStats) String() string {
+	return fmt.Sprintf("MinerStats<%s>", m.MinerAddress)
+}
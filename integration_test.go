@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const testAddress = "0x0000000000000000000000000000000000000001"
+
+// fakeFlexpoolAPI serves fixed responses for the routes used by the assistant
+// The balance can be changed between two runs to trigger notifications
+type fakeFlexpoolAPI struct {
+	balance int64
+}
+
+func (f *fakeFlexpoolAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().Unix()
+	var result string
+	switch r.URL.Path {
+	case "/v2/miner/balance":
+		result = fmt.Sprintf(`{"balance": %d}`, f.balance)
+	case "/v2/miner/stats":
+		result = `{"currentEffectiveHashrate": 100000000, "averageEffectiveHashrate": 90000000, "reportedHashrate": 95000000, "validShares": 1000, "staleShares": 10, "invalidShares": 1}`
+	case "/v2/miner/payments/":
+		result = fmt.Sprintf(`{"totalPages": 1, "data": [{"hash": "0xpayment", "value": 100000000000000000, "fee": 1000000000000000, "timestamp": %d, "confirmed": true}]}`, now-3600)
+	case "/v2/miner/details":
+		result = `{"payoutLimit": 100000000000000000, "maxFeePrice": 10, "network": "mainnet"}`
+	case "/v2/miner/workers":
+		result = fmt.Sprintf(`[{"name": "rig-01", "isOnline": true, "lastSeen": %d, "reportedHashrate": 50000000, "currentEffectiveHashrate": 50000000, "averageEffectiveHashrate": 45000000, "validShares": 500, "staleShares": 5, "invalidShares": 0}, {"name": "rig-02", "isOnline": false, "lastSeen": %d, "validShares": 0}]`, now, now-7200)
+	case "/v2/miner/estimatedDailyRevenue":
+		result = `10000000000000000`
+	case "/v2/pool/blocks/":
+		result = fmt.Sprintf(`{"totalPages": 1, "data": [{"hash": "0xblock", "number": 13000000, "type": "block", "miner": "%s", "reward": 2000000000000000000, "luck": 0.8, "confirmed": false, "timestamp": %d}]}`, testAddress, now-600)
+	case "/v2/pool/hashrate":
+		result = `{"total": 1000000000000}`
+	case "/v2/pool/currentLuck", "/v2/pool/averageLuck":
+		result = `0.9`
+	case "/v2/pool/networkDifficulty":
+		result = `10000000000000000`
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"error": null, "result": %s}`, result)
+}
+
+// rewriteTransport sends requests to the Flexpool API to a test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (r *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = r.target.Scheme
+	request.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+// newTestClient creates a FlexpoolClient requesting the test server instead of the Flexpool API
+func newTestClient(t *testing.T, handler http.Handler) *FlexpoolClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewFlexpoolClient()
+	client.client.Transport = &rewriteTransport{target: target}
+	return client
+}
+
+// resetDatabase drops all relations to run the test on an empty database
+func resetDatabase(t *testing.T, db *gorm.DB) {
+	models := []interface{}{&SchemaMigration{}, &Miner{}, &Worker{}, &ExpectedWorker{}, &Pool{}, &Block{}, &Payment{},
+		&BalanceRecord{}, &WorkerRecord{}, &MinerStats{}, &Report{}}
+	if err := db.Migrator().DropTable(models...); err != nil {
+		t.Fatalf("Cannot reset database: %v", err)
+	}
+}
+
+// testAssistantCycle migrates an empty database then runs the assistant twice against a fake API
+func testAssistantCycle(t *testing.T, db *gorm.DB) {
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+
+	config := NewConfig()
+	config.Miners = []MinerConfig{{
+		Address:              testAddress,
+		Coin:                 "eth",
+		EnableStats:          true,
+		EnableBalance:        true,
+		EnablePayments:       true,
+		EnablePayoutSettings: true,
+		EnableOfflineWorkers: true,
+		BalanceAlerts:        BalanceAlertsConfig{Thresholds: []float64{0.5}, StalledAfter: time.Hour},
+		ShareAlerts:          ShareAlertsConfig{MaxStaleRatio: 5},
+	}}
+	config.Pools = []PoolConfig{{Coin: "eth", EnableStats: true, EnableBlocks: true}}
+	config.Reports = []ReportConfig{{Name: "daily", Schedule: "@daily"}}
+
+	api := &fakeFlexpoolAPI{balance: 100000000000000000}
+	notifier := &TelegramNotifier{configurations: &NotificationsConfig{Digest: DigestConfig{Enable: true}}}
+	assistant := NewAssistant(config, db, newTestClient(t, api), notifier)
+
+	// First run stores the state without notifications
+	assistant.Run()
+	if len(notifier.events) != 0 {
+		t.Errorf("Expected no event on first run, got %d", len(notifier.events))
+	}
+	counts := []struct {
+		model    interface{}
+		expected int64
+	}{
+		{&Miner{}, 1},
+		{&Worker{}, 2},
+		{&WorkerRecord{}, 2},
+		{&MinerStats{}, 1},
+		{&BalanceRecord{}, 1},
+		{&Payment{}, 1},
+		{&Pool{}, 1},
+		{&Block{}, 1},
+		{&Report{}, 1},
+	}
+	for _, count := range counts {
+		var actual int64
+		if trx := db.Model(count.model).Count(&actual); trx.Error != nil {
+			t.Fatalf("Cannot count %T: %v", count.model, trx.Error)
+		}
+		if actual != count.expected {
+			t.Errorf("Expected %d %T, got %d", count.expected, count.model, actual)
+		}
+	}
+
+	var report Report
+	if trx := db.Where(Report{Name: "daily"}).First(&report); trx.Error != nil {
+		t.Fatalf("Cannot fetch report: %v", trx.Error)
+	}
+	if report.LastRunAt == nil {
+		t.Error("Expected report to be scheduled")
+	}
+	var miner Miner
+	if trx := db.Where(Miner{Address: testAddress}).First(&miner); trx.Error != nil {
+		t.Fatalf("Cannot fetch miner: %v", trx.Error)
+	}
+	if miner.BalanceIncreasedAt == nil {
+		t.Error("Expected balance increase time to be set")
+	}
+
+	// Second run notifies the balance crossing its threshold
+	api.balance = 600000000000000000
+	assistant.Run()
+	types := map[string]bool{}
+	for _, event := range notifier.events {
+		types[event.Type] = true
+	}
+	for _, eventType := range []string{"balance", "balance-alert"} {
+		if !types[eventType] {
+			t.Errorf("Expected %s event, got %v", eventType, types)
+		}
+	}
+}
+
+// newTestDatabase creates an empty in-memory SQLite database
+func newTestDatabase(t *testing.T) *gorm.DB {
+	db, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each connection opens a distinct in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// baselineSchema to store relations created by the first release supporting migrations
+var baselineSchema = []string{
+	"CREATE TABLE `miners` (`id` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`coin` text,`address` text NOT NULL UNIQUE,`balance` real,`last_payment_timestamp` integer,PRIMARY KEY (`id`))",
+	"CREATE INDEX `idx_miners_deleted_at` ON `miners`(`deleted_at`)",
+	"CREATE TABLE `workers` (`id` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`miner_address` text NOT NULL,`name` text NOT NULL,`is_online` numeric NOT NULL,`last_seen` datetime NOT NULL,PRIMARY KEY (`id`))",
+	"CREATE INDEX `idx_workers_deleted_at` ON `workers`(`deleted_at`)",
+	"CREATE TABLE `pools` (`id` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`coin` text NOT NULL UNIQUE,`last_block_number` integer,PRIMARY KEY (`id`))",
+	"CREATE INDEX `idx_pools_deleted_at` ON `pools`(`deleted_at`)",
+}
+
+// newBaselineDatabase creates an in-memory SQLite database with the baseline schema and the given rows
+func newBaselineDatabase(t *testing.T, inserts ...string) *gorm.DB {
+	db := newTestDatabase(t)
+	for _, statement := range append(baselineSchema, inserts...) {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("Cannot create baseline database: %v", err)
+		}
+	}
+	return db
+}
+
+func TestAssistantCycleSQLite(t *testing.T) {
+	testAssistantCycle(t, newTestDatabase(t))
+}
+
+// testAssistantCycleDSN runs the cycle on the database given by the environment variable
+// The database is reset, it must be dedicated to tests
+func testAssistantCycleDSN(t *testing.T, variable string) {
+	dsn := os.Getenv(variable)
+	if dsn == "" {
+		t.Skipf("%s is not set", variable)
+	}
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetDatabase(t, db)
+	testAssistantCycle(t, db)
+}
+
+func TestAssistantCyclePostgres(t *testing.T) {
+	testAssistantCycleDSN(t, "FLEXASSISTANT_TEST_POSTGRES_DSN")
+}
+
+func TestAssistantCycleMySQL(t *testing.T) {
+	testAssistantCycleDSN(t, "FLEXASSISTANT_TEST_MYSQL_DSN")
+}
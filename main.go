@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -29,6 +31,60 @@ const MaxPayments = 10
 // MaxBlocks defaults
 const MaxBlocks = 50
 
+// FlapWindow defaults to count online and offline transitions of workers
+const FlapWindow = time.Hour
+
+// HashrateWindow defaults to compute the rolling average hashrate of workers
+const HashrateWindow = 6 * time.Hour
+
+// SharesWindow defaults to compute the stale and invalid shares ratios of workers
+const SharesWindow = time.Hour
+
+// WorkerRetention defaults to delete workers that have not been seen for a week
+const WorkerRetention = 7 * 24 * time.Hour
+
+// BalanceHistoryRetention defaults to delete balance history after 90 days
+const BalanceHistoryRetention = 90 * 24 * time.Hour
+
+// WorkerHistoryRetention defaults to delete worker events after 30 days
+const WorkerHistoryRetention = 30 * 24 * time.Hour
+
+// MinerStatsRetention defaults to delete miner statistics after 90 days
+const MinerStatsRetention = 90 * 24 * time.Hour
+
+// HourlyDownsamplingAfter defaults to aggregate history older than a week per hour
+const HourlyDownsamplingAfter = 7 * 24 * time.Hour
+
+// DailyDownsamplingAfter defaults to aggregate history older than 30 days per day
+const DailyDownsamplingAfter = 30 * 24 * time.Hour
+
+// DownsamplingBatchSize is the number of history records read at once while downsampling
+const DownsamplingBatchSize = 1000
+
+// RetentionInterval defaults between two applications of retention policies in daemon mode
+const RetentionInterval = 24 * time.Hour
+
+// VacuumInterval defaults between two SQLite VACUUM in daemon mode
+const VacuumInterval = 7 * 24 * time.Hour
+
+// EarningsWindow defaults to estimate daily earnings from the balance history
+const EarningsWindow = 24 * time.Hour
+
+// MinEarningsSpan is the minimum duration of balance history required to estimate daily earnings
+const MinEarningsSpan = time.Hour
+
+// MaxPayoutETA is the longest payout estimation, farther payouts are unknown
+const MaxPayoutETA = 10 * 365 * 24 * time.Hour
+
+// MaxDigestEvents is the number of events kept for the next digest, oldest events are dropped beyond
+const MaxDigestEvents = 100
+
+// MaxMessageLength is the number of characters accepted by Telegram in a message
+const MaxMessageLength = 4096
+
+// Interval defaults between two runs in daemon mode
+const Interval = 5 * time.Minute
+
 // initialize logging
 func init() {
 	log.SetOutput(os.Stdout)
@@ -38,6 +94,7 @@ func init() {
 func main() {
 	config := NewConfig()
 	version := flag.Bool("version", false, "Print version and exit")
+	daemon := flag.Bool("daemon", false, "Run continuously and check the API every interval")
 	quiet := flag.Bool("quiet", false, "Log errors only")
 	verbose := flag.Bool("verbose", false, "Print more logs")
 	debug := flag.Bool("debug", false, "Print even more logs")
@@ -68,26 +125,59 @@ func main() {
 		}
 	}
 
+	// Coins
+	for _, coin := range config.Coins {
+		if err := Coins.Register(coin); err != nil {
+			log.Fatalf("Cannot register coin: %v", err)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// Database
 	var db *gorm.DB
-	db, err := NewDatabase(config.DatabaseFile)
+	db, err := NewDatabase(config.DatabaseDSN())
 	if err != nil {
 		log.Fatalf("Could not create database: %v", err)
 	}
 
-	if err := CreateDatabaseObjects(db); err != nil {
-		log.Fatalf("Could not create objects: %v", err)
+	// Migrations are managed by the command itself
+	if flag.Arg(0) == "migrate" {
+		if err := RunMigrateCommand(flag.Args()[1:], db); err != nil {
+			log.Fatalf("Command migrate failed: %v", err)
+		}
+		return
 	}
 
-	if err := EnsureDatabaseRetention(db); err != nil {
-		log.Fatalf("Could not cleanup objects from database: %v", err)
+	if err := MigrateDatabase(db); err != nil {
+		log.Fatalf("Could not migrate database: %v", err)
 	}
 
 	// API client
 	client := NewFlexpoolClient()
 
+	// Prices
+	prices, err := NewPriceProvider(&config.Prices)
+	if err != nil {
+		log.Fatalf("Could not create price provider: %v", err)
+	}
+
+	// Commands
+	if flag.NArg() > 0 {
+		if err := RunCommand(flag.Arg(0), flag.Args()[1:], config, db, client, prices); err != nil {
+			log.Fatalf("Command %s failed: %v", flag.Arg(0), err)
+		}
+		return
+	}
+
+	// Retention is applied after commands so that export reads the complete history
+	if err := EnsureDatabaseRetention(db, &config.Retention); err != nil {
+		log.Fatalf("Could not cleanup objects from database: %v", err)
+	}
+
 	// Notifications
-	notifier, err := NewTelegramNotifier(&config.TelegramConfig, &config.Notifications)
+	notifier, err := NewTelegramNotifier(&config.TelegramConfig, &config.Notifications, prices, config.Prices.Currency)
 	if err != nil {
 		log.Fatalf("Could not create notifier: %v", err)
 	}
@@ -97,199 +187,64 @@ func main() {
 		log.Fatalf("Could not send test notifications: %v", err)
 	}
 	if executed {
+		if err := notifier.Flush(true); err != nil {
+			log.Fatalf("Could not send test digest notification: %v", err)
+		}
 		log.Debug("Exit after sending test notifications")
 		return
 	}
 
-	// Limits
-	var maxPayments int
-	if config.MaxPayments > 0 {
-		maxPayments = config.MaxPayments
-	} else {
-		maxPayments = MaxPayments
-	}
-
-	var maxBlocks int
-	if config.MaxBlocks > 0 {
-		maxBlocks = config.MaxBlocks
-	} else {
-		maxBlocks = MaxBlocks
-	}
-
-	// Handle miners
-	for _, configuredMiner := range config.Miners {
-		miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
-		if err != nil {
-			log.Warnf("Could not parse miner: %v", err)
-			continue
-		}
+	assistant := NewAssistant(config, db, client, notifier)
 
-		var dbMiner Miner
-		trx := db.Where(Miner{Address: miner.Address}).Attrs(Miner{Address: miner.Address, Coin: miner.Coin}).FirstOrCreate(&dbMiner)
-		if trx.Error != nil {
-			log.Warnf("Cannot fetch miner %s from database: %v", miner, trx.Error)
+	if !*daemon {
+		assistant.Run()
+		if err := notifier.Flush(true); err != nil {
+			log.Warnf("Cannot send digest notification: %v", err)
 		}
+		return
+	}
 
-		// Balance management
-		if configuredMiner.EnableBalance {
-			// Balance have never been persisted, skip notifications
-			notify := true
-			if dbMiner.Balance == 0 {
-				notify = false
-			}
-
-			log.Debugf("Fetching balance for %s", miner)
-			balance, err := client.MinerBalance(miner.Coin, miner.Address)
-			if err != nil {
-				log.Warnf("Could not fetch unpaid balance: %v", err)
-				continue
-			}
-			log.Debugf("Unpaid balance %.0f", balance)
-			miner.Balance = balance
-			if miner.Balance != dbMiner.Balance {
-				dbMiner.Balance = balance
-				if trx = db.Save(&dbMiner); trx.Error != nil {
-					log.Warnf("Cannot update miner: %v", trx.Error)
-					continue
-				}
-				if notify {
-					err = notifier.NotifyBalance(*miner)
-					if err != nil {
-						log.Warnf("Cannot send notification: %v", err)
-						continue
-					}
-					log.Infof("Balance notification sent for %s", miner)
-				}
-			}
+	// Daemon mode
+	interval := Interval
+	if config.Interval > 0 {
+		interval = config.Interval
+	}
+	log.Infof("Running every %s", interval)
+	lastRetention := time.Now()
+	lastVacuum := time.Now()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		assistant.Run()
+		if err := notifier.Flush(false); err != nil {
+			log.Warnf("Cannot send digest notification: %v", err)
 		}
 
-		// Payments management
-		if configuredMiner.EnablePayments {
-			// Payments have never been persisted, skip notifications
-			notify := true
-			if dbMiner.LastPaymentTimestamp == 0 {
-				notify = false
-			}
-
-			log.Debugf("Fetching payments for %s", miner)
-
-			payments, err := client.MinerPayments(miner.Coin, miner.Address, maxPayments)
-			if err != nil {
-				log.Warnf("Could not fetch payments: %v", err)
-				continue
-			}
-			for _, payment := range payments {
-				log.Debugf("Fetched %s", payment)
-				if dbMiner.LastPaymentTimestamp < payment.Timestamp {
-					dbMiner.LastPaymentTimestamp = payment.Timestamp
-					if trx = db.Save(&dbMiner); trx.Error != nil {
-						log.Warnf("Cannot update miner: %v", trx.Error)
-						continue
-					}
-					if notify {
-						err = notifier.NotifyPayment(*miner, *payment)
-						if err != nil {
-							log.Warnf("Cannot send notification: %v", err)
-							continue
-						}
-						log.Infof("Payment notification sent for %s", payment)
-					}
-				}
+		if config.Retention.Interval > 0 && time.Since(lastRetention) >= config.Retention.Interval {
+			if err := EnsureDatabaseRetention(db, &config.Retention); err != nil {
+				log.Warnf("Cannot cleanup objects from database: %v", err)
 			}
+			lastRetention = time.Now()
 		}
-
-		// Offline workers management
-		if configuredMiner.EnableOfflineWorkers {
-			log.Debugf("Fetching workers for %s", miner)
-
-			workers, err := client.MinerWorkers(miner.Coin, miner.Address)
-			if err != nil {
-				log.Warnf("Could not fetch workers: %v", err)
-				continue
-			}
-			for _, worker := range workers {
-				log.Debugf("Fetched %s", worker)
-
-				var dbWorker Worker
-				trx := db.Where(Worker{MinerAddress: miner.Address, Name: worker.Name}).Attrs(Worker{MinerAddress: miner.Address, Name: worker.Name}).FirstOrCreate(&dbWorker)
-				if trx.Error != nil {
-					log.Warnf("Cannot fetch worker %s from database: %v", worker, trx.Error)
-					continue
-				}
-
-				if dbWorker.IsOnline != worker.IsOnline {
-					// Skip first notification
-					notify := true
-					if dbWorker.LastSeen.IsZero() {
-						notify = false
-					}
-					dbWorker.IsOnline = worker.IsOnline
-					dbWorker.LastSeen = worker.LastSeen
-					if trx = db.Save(&dbWorker); trx.Error != nil {
-						log.Warnf("Cannot update worker: %v", trx.Error)
-						continue
-					}
-					if notify {
-						err = notifier.NotifyOfflineWorker(*worker)
-						if err != nil {
-							log.Warnf("Cannot send notification: %v", err)
-							continue
-						}
-						log.Infof("Offline worker notification sent for %s", worker)
-					}
-				}
+		if config.Retention.VacuumInterval > 0 && time.Since(lastVacuum) >= config.Retention.VacuumInterval {
+			if err := VacuumDatabase(db); err != nil {
+				log.Warnf("Cannot vacuum database: %v", err)
 			}
+			lastVacuum = time.Now()
 		}
-	}
-
-	// Handle pools
-	for _, configuredPool := range config.Pools {
-
-		pool := NewPool(configuredPool.Coin)
-
-		var dbPool Pool
-		trx := db.Where(Pool{Coin: pool.Coin}).Attrs(Pool{Coin: pool.Coin}).FirstOrCreate(&dbPool)
-		if trx.Error != nil {
-			log.Warnf("Cannot fetch pool %s from database: %v", pool, trx.Error)
-		}
-
-		// Blocks management
-		if configuredPool.EnableBlocks {
-
-			// Block number has never been persisted, skip notifications
-			notify := true
-			if dbPool.LastBlockNumber == 0 {
-				notify = false
-			}
 
-			log.Debugf("Fetching blocks for %s", pool)
-			blocks, err := client.PoolBlocks(pool.Coin, maxBlocks)
-			if err != nil {
-				log.Warnf("Could not fetch blocks: %v", err)
-			} else {
-				for _, block := range blocks {
-					log.Debugf("Fetched %s", block)
-					if dbPool.LastBlockNumber < block.Number {
-						dbPool.LastBlockNumber = block.Number
-						if trx = db.Save(&dbPool); trx.Error != nil {
-							log.Warnf("Cannot update pool: %v", trx.Error)
-							continue
-						}
-						convertedReward, err := ConvertCurrency(pool.Coin, block.Reward)
-						if err != nil {
-							log.Warnf("Reward for block %d cannot be converted: %v", block.Number, err)
-						}
-						if notify && convertedReward >= configuredPool.MinBlockReward {
-							err = notifier.NotifyBlock(*pool, *block)
-							if err != nil {
-								log.Warnf("Cannot send notification: %v", err)
-								continue
-							}
-							log.Infof("Block notification sent for %s", block)
-						}
-					}
-				}
+		select {
+		case <-ticker.C:
+		case sig := <-signals:
+			log.Infof("Received %s signal, exiting", sig)
+			if err := notifier.Flush(true); err != nil {
+				log.Warnf("Cannot send digest notification: %v", err)
 			}
+			return
 		}
 	}
 }
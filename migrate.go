@@ -0,0 +1,446 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchemaMigration to store a migration applied to the database
+type SchemaMigration struct {
+	Version     int `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+	AppliedAt   time.Time
+}
+
+// String represents SchemaMigration to a printable format
+func (s *SchemaMigration) String() string {
+	return fmt.Sprintf("SchemaMigration<%d>", s.Version)
+}
+
+// Migration to store a versioned change of the database schema
+// Tables and new columns are created by CreateDatabaseObjects, migrations handle changes AutoMigrate cannot do (column
+// types, data conversions)
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// migrations to store all migrations ordered by version
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "store pool block numbers as integers",
+		Up:          upIntegerBlockNumbers,
+		Down:        downIntegerBlockNumbers,
+	},
+	{
+		Version:     2,
+		Description: "store amounts as exact integers",
+		Up:          upExactAmounts,
+		Down:        downExactAmounts,
+	},
+	{
+		Version:     3,
+		Description: "store unknown times as null",
+		Up:          upNullTimes,
+		Down:        downNullTimes,
+	},
+}
+
+// MigrateDatabase applies pending migrations after a backup then creates database objects
+// A new database is created with the latest schema and all migrations are marked as applied
+func MigrateDatabase(db *gorm.DB) error {
+	fresh, err := prepareMigrations(db)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		if err = CreateDatabaseObjects(db); err != nil {
+			return err
+		}
+		return baselineMigrations(db)
+	}
+	if err = applyMigrations(db); err != nil {
+		return err
+	}
+	return CreateDatabaseObjects(db)
+}
+
+// prepareMigrations creates the migrations table and returns true when the database has never been initialized
+func prepareMigrations(db *gorm.DB) (fresh bool, err error) {
+	migrator := db.Migrator()
+	fresh = !migrator.HasTable(&Miner{}) && !migrator.HasTable(&Pool{})
+	if err = db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return false, err
+	}
+	return fresh, nil
+}
+
+// baselineMigrations marks all migrations as applied
+func baselineMigrations(db *gorm.DB) error {
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		record := SchemaMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+		if trx := db.Create(&record); trx.Error != nil {
+			return trx.Error
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns applied migrations by version
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	var records []SchemaMigration
+	if trx := db.Order("version").Find(&records); trx.Error != nil {
+		return nil, trx.Error
+	}
+	applied := make(map[int]SchemaMigration)
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// pendingMigrations returns migrations that have not been applied yet
+func pendingMigrations(db *gorm.DB) (pending []Migration, err error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// applyMigrations applies pending migrations in order, after a backup of the database
+func applyMigrations(db *gorm.DB) error {
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if err = BackupDatabase(db); err != nil {
+		return fmt.Errorf("Cannot backup database before migrations: %v", err)
+	}
+
+	for _, migration := range pending {
+		log.Infof("Applying migration %d (%s)", migration.Version, migration.Description)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			record := SchemaMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+			return tx.Create(&record).Error
+		})
+		if err != nil {
+			return fmt.Errorf("Migration %d failed: %v", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+// revertMigration reverts the last applied migration, after a backup of the database
+func revertMigration(db *gorm.DB) (*Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var last *Migration
+	for i := range migrations {
+		if _, ok := applied[migrations[i].Version]; ok {
+			last = &migrations[i]
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("No migration to revert")
+	}
+	if err = BackupDatabase(db); err != nil {
+		return nil, fmt.Errorf("Cannot backup database before migrations: %v", err)
+	}
+
+	log.Infof("Reverting migration %d (%s)", last.Version, last.Description)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := last.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, last.Version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Migration %d failed: %v", last.Version, err)
+	}
+	return last, nil
+}
+
+// BackupDatabase copies a SQLite database next to its file with the current time as suffix
+// Other databases must be saved with their own tools
+func BackupDatabase(db *gorm.DB) error {
+	dialector, ok := db.Dialector.(*sqlite.Dialector)
+	if !ok {
+		log.Warnf("Automatic backup is not supported by %s, make sure the database has been saved", db.Dialector.Name())
+		return nil
+	}
+	filename := strings.TrimPrefix(dialector.DSN, "file:")
+	if i := strings.Index(filename, "?"); i != -1 {
+		filename = filename[:i]
+	}
+	if filename == "" || filename == ":memory:" {
+		return nil
+	}
+	backup := fmt.Sprintf("%s.%s.bak", filename, time.Now().Format("20060102150405.000"))
+	log.Infof("Saving database to %s", backup)
+	return db.Exec("VACUUM INTO ?", backup).Error
+}
+
+// isSQLite returns true when the database is SQLite
+// Migrations of types used before other databases were supported only apply to SQLite
+func isSQLite(tx *gorm.DB) bool {
+	return tx.Dialector.Name() == "sqlite"
+}
+
+// columnNotNull returns true when a SQLite column has a NOT NULL constraint
+func columnNotNull(tx *gorm.DB, table string, column string) (bool, error) {
+	var notNull bool
+	trx := tx.Raw(`SELECT "notnull" FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&notNull)
+	return notNull, trx.Error
+}
+
+// columnType returns the declared type of a SQLite column in lower case or an empty string when it doesn't exist
+func columnType(tx *gorm.DB, table string, column string) (string, error) {
+	var declared string
+	trx := tx.Raw("SELECT type FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&declared)
+	return strings.ToLower(declared), trx.Error
+}
+
+// convertColumn replaces a column with a new definition and copies its values converted by a function
+func convertColumn(tx *gorm.DB, table string, column string, definition string, convert func(value interface{}) (interface{}, error)) error {
+	log.Infof("Converting %s.%s to %s", table, column, definition)
+	old := column + "_old"
+	if err := tx.Exec("ALTER TABLE ? RENAME COLUMN ? TO ?", clause.Table{Name: table}, clause.Column{Name: column}, clause.Column{Name: old}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(fmt.Sprintf("ALTER TABLE ? ADD COLUMN ? %s", definition), clause.Table{Name: table}, clause.Column{Name: column}).Error; err != nil {
+		return err
+	}
+
+	type row struct {
+		id    uint
+		value interface{}
+	}
+	var values []row
+	rows, err := tx.Raw("SELECT id, ? FROM ?", clause.Column{Name: old}, clause.Table{Name: table}).Rows()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var r row
+		if err = rows.Scan(&r.id, &r.value); err != nil {
+			rows.Close()
+			return err
+		}
+		values = append(values, r)
+	}
+	rows.Close()
+
+	for _, r := range values {
+		converted, err := convert(r.value)
+		if err != nil {
+			return fmt.Errorf("Cannot convert %s.%s of row %d: %v", table, column, r.id, err)
+		}
+		if err = tx.Exec("UPDATE ? SET ? = ? WHERE id = ?", clause.Table{Name: table}, clause.Column{Name: column}, converted, r.id).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: old}).Error
+}
+
+// upIntegerBlockNumbers converts block numbers stored as floating point numbers before 1.1
+func upIntegerBlockNumbers(tx *gorm.DB) error {
+	if !isSQLite(tx) {
+		return nil
+	}
+	declared, err := columnType(tx, "pools", "last_block_number")
+	if err != nil || declared != "real" {
+		return err
+	}
+	return convertColumn(tx, "pools", "last_block_number", "integer", func(value interface{}) (interface{}, error) {
+		if number, ok := value.(float64); ok {
+			return uint64(number), nil
+		}
+		return value, nil
+	})
+}
+
+// downIntegerBlockNumbers converts block numbers back to floating point numbers
+func downIntegerBlockNumbers(tx *gorm.DB) error {
+	if !isSQLite(tx) {
+		return nil
+	}
+	declared, err := columnType(tx, "pools", "last_block_number")
+	if err != nil || declared != "integer" {
+		return err
+	}
+	return convertColumn(tx, "pools", "last_block_number", "real", func(value interface{}) (interface{}, error) {
+		if number, ok := value.(int64); ok {
+			return float64(number), nil
+		}
+		return value, nil
+	})
+}
+
+// amountColumns to store columns of amounts with their definition
+var amountColumns = []struct {
+	table    string
+	column   string
+	required bool
+}{
+	{"miners", "balance", false},
+	{"miners", "last_notified_balance", false},
+	{"miners", "payout_limit", false},
+	{"balance_records", "value", true},
+	{"payments", "value", true},
+	{"payments", "fee", false},
+	{"blocks", "reward", true},
+}
+
+// upExactAmounts converts amounts stored as integers (before 1.3) or floating point numbers to exact amounts
+func upExactAmounts(tx *gorm.DB) error {
+	if !isSQLite(tx) {
+		return nil
+	}
+	for _, amount := range amountColumns {
+		declared, err := columnType(tx, amount.table, amount.column)
+		if err != nil {
+			return err
+		}
+		if declared == "" || declared == "text" {
+			continue
+		}
+		definition := "text"
+		if amount.required {
+			definition = "text NOT NULL DEFAULT '0'"
+		}
+		err = convertColumn(tx, amount.table, amount.column, definition, func(value interface{}) (interface{}, error) {
+			var converted Amount
+			if err := converted.Scan(value); err != nil {
+				return nil, err
+			}
+			return converted, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downExactAmounts converts exact amounts back to floating point numbers, large amounts lose precision
+func downExactAmounts(tx *gorm.DB) error {
+	if !isSQLite(tx) {
+		return nil
+	}
+	for _, amount := range amountColumns {
+		declared, err := columnType(tx, amount.table, amount.column)
+		if err != nil {
+			return err
+		}
+		if declared != "text" {
+			continue
+		}
+		definition := "real"
+		if amount.required {
+			definition = "real NOT NULL DEFAULT 0"
+		}
+		err = convertColumn(tx, amount.table, amount.column, definition, func(value interface{}) (interface{}, error) {
+			var converted Amount
+			if err := converted.Scan(value); err != nil {
+				return nil, err
+			}
+			return converted.Float64(), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeColumns to store columns of times that are unknown until the first observation
+var timeColumns = []struct {
+	table  string
+	column string
+}{
+	{"miners", "balance_increased_at"},
+	{"workers", "last_seen"},
+	{"workers", "state_changed_at"},
+	{"workers", "offline_at"},
+	{"reports", "last_run_at"},
+}
+
+// upNullTimes replaces zero times by null values, MySQL rejects zero dates in strict mode
+func upNullTimes(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn("workers", "last_seen") {
+		switch tx.Dialector.Name() {
+		case "sqlite":
+			notNull, err := columnNotNull(tx, "workers", "last_seen")
+			if err != nil {
+				return err
+			}
+			if notNull {
+				err = convertColumn(tx, "workers", "last_seen", "datetime", func(value interface{}) (interface{}, error) {
+					return value, nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		case "postgres":
+			if err := tx.Exec("ALTER TABLE workers ALTER COLUMN last_seen DROP NOT NULL").Error; err != nil {
+				return err
+			}
+		case "mysql":
+			if err := tx.Exec("ALTER TABLE workers MODIFY last_seen datetime(3) NULL").Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, column := range timeColumns {
+		if !tx.Migrator().HasColumn(column.table, column.column) {
+			continue
+		}
+		trx := tx.Exec("UPDATE ? SET ? = NULL WHERE ? = ?", clause.Table{Name: column.table}, clause.Column{Name: column.column}, clause.Column{Name: column.column}, time.Time{})
+		if trx.Error != nil {
+			return trx.Error
+		}
+	}
+	return nil
+}
+
+// downNullTimes replaces null values by zero times
+func downNullTimes(tx *gorm.DB) error {
+	for _, column := range timeColumns {
+		if !tx.Migrator().HasColumn(column.table, column.column) {
+			continue
+		}
+		trx := tx.Exec("UPDATE ? SET ? = ? WHERE ? IS NULL", clause.Table{Name: column.table}, clause.Column{Name: column.column}, time.Time{}, clause.Column{Name: column.column})
+		if trx.Error != nil {
+			return trx.Error
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newFileDatabase creates a SQLite database in a temporary directory, backups are written next to it
+func newFileDatabase(t *testing.T, statements ...string) (*gorm.DB, string) {
+	directory := t.TempDir()
+	db, err := NewDatabase("sqlite://" + filepath.Join(directory, "flexassistant.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("Cannot create database: %v", err)
+		}
+	}
+	return db, directory
+}
+
+// backups returns backup files of the database in a directory
+func backups(t *testing.T, directory string) []string {
+	files, err := filepath.Glob(filepath.Join(directory, "flexassistant.db.*.bak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// legacySchema returns the baseline schema with a column declared with an older type
+func legacySchema(table string, column string, declared string) []string {
+	var statements []string
+	for _, statement := range baselineSchema {
+		if strings.HasPrefix(statement, "CREATE TABLE `"+table+"`") {
+			statement = strings.Replace(statement, "`"+column+"` integer", "`"+column+"` "+declared, 1)
+			statement = strings.Replace(statement, "`"+column+"` real", "`"+column+"` "+declared, 1)
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+func TestMigrateDatabaseColumns(t *testing.T) {
+	tests := []struct {
+		name         string
+		schema       []string
+		insert       string
+		table        string
+		column       string
+		expectedType string
+		expected     string
+	}{
+		{
+			name:         "real balance",
+			schema:       baselineSchema,
+			insert:       "INSERT INTO miners (coin, address, balance) VALUES ('eth', '" + testAddress + "', 1.5e17)",
+			table:        "miners",
+			column:       "balance",
+			expectedType: "text",
+			expected:     "150000000000000000",
+		},
+		{
+			name:         "integer balance before 1.3",
+			schema:       legacySchema("miners", "balance", "integer"),
+			insert:       "INSERT INTO miners (coin, address, balance) VALUES ('eth', '" + testAddress + "', 150000000000000000)",
+			table:        "miners",
+			column:       "balance",
+			expectedType: "text",
+			expected:     "150000000000000000",
+		},
+		{
+			name:         "real block number before 1.1",
+			schema:       legacySchema("pools", "last_block_number", "real"),
+			insert:       "INSERT INTO pools (coin, last_block_number) VALUES ('eth', 13000000.0)",
+			table:        "pools",
+			column:       "last_block_number",
+			expectedType: "integer",
+			expected:     "13000000",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, directory := newFileDatabase(t, append(tc.schema, tc.insert)...)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatalf("Cannot migrate database: %v", err)
+			}
+
+			declared, err := columnType(db, tc.table, tc.column)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if declared != tc.expectedType {
+				t.Errorf("Expected %s.%s to be %s, got %s", tc.table, tc.column, tc.expectedType, declared)
+			}
+			var value string
+			if trx := db.Table(tc.table).Select("CAST(? AS text)", gorm.Expr(tc.column)).Scan(&value); trx.Error != nil {
+				t.Fatal(trx.Error)
+			}
+			if value != tc.expected {
+				t.Errorf("Expected %s.%s to be %s, got %s", tc.table, tc.column, tc.expected, value)
+			}
+			if files := backups(t, directory); len(files) != 1 {
+				t.Errorf("Expected a backup before migrations, got %v", files)
+			}
+		})
+	}
+}
+
+func TestMigrateDatabaseUpDown(t *testing.T) {
+	db, directory := newFileDatabase(t, append(baselineSchema,
+		"INSERT INTO miners (coin, address, balance) VALUES ('eth', '"+testAddress+"', 1.5e17)",
+		"INSERT INTO workers (miner_address, name, is_online, last_seen) VALUES ('"+testAddress+"', 'rig-01', 1, '0001-01-01 00:00:00+00:00')",
+	)...)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("Expected %d applied migrations, got %d", len(migrations), len(applied))
+	}
+	notNull, err := columnNotNull(db, "workers", "last_seen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var worker Worker
+	if trx := db.First(&worker); trx.Error != nil {
+		t.Fatal(trx.Error)
+	}
+	if notNull || worker.LastSeen != nil {
+		t.Errorf("Expected unknown last seen time to be null, got %v (not null: %t)", worker.LastSeen, notNull)
+	}
+
+	// Revert all migrations, each of them after a backup
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration, err := revertMigration(db)
+		if err != nil {
+			t.Fatalf("Cannot revert migration: %v", err)
+		}
+		if migration.Version != migrations[i].Version {
+			t.Errorf("Expected migration %d to be reverted, got %d", migrations[i].Version, migration.Version)
+		}
+	}
+	if _, err = revertMigration(db); err == nil {
+		t.Error("Expected no migration to revert")
+	}
+	if files := backups(t, directory); len(files) != 1+len(migrations) {
+		t.Errorf("Expected %d backups, got %d", 1+len(migrations), len(files))
+	}
+	if declared, err := columnType(db, "miners", "balance"); err != nil || declared != "real" {
+		t.Errorf("Expected balance to be real, got %s (%v)", declared, err)
+	}
+	var lastSeen string
+	if trx := db.Raw("SELECT last_seen FROM workers").Scan(&lastSeen); trx.Error != nil || lastSeen == "" {
+		t.Errorf("Expected last seen time to be a zero time, got %q (%v)", lastSeen, trx.Error)
+	}
+
+	// Applying migrations again keeps the data
+	if err = MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+	var miner Miner
+	if trx := db.First(&miner); trx.Error != nil {
+		t.Fatal(trx.Error)
+	}
+	if miner.Balance.String() != "150000000000000000" {
+		t.Errorf("Expected balance to be kept, got %s", miner.Balance)
+	}
+}
+
+func TestMigrateDatabaseFresh(t *testing.T) {
+	db, directory := newFileDatabase(t)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected all migrations to be marked as applied, got %d pending", len(pending))
+	}
+	if files := backups(t, directory); len(files) != 0 {
+		t.Errorf("Expected no backup of a new database, got %v", files)
+	}
+}
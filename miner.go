@@ -2,25 +2,27 @@ package main
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// CoinLengthETH represents the size of an ETH address
-const CoinLengthETH = 42
-
-// CoinLengthXCH represents the size of an XCH address
-const CoinLengthXCH = 62
-
 // Miner to store miner attributes
 type Miner struct {
 	gorm.Model
 	Coin                 string
-	Address              string `gorm:"unique;not null"`
-	Balance              float64
+	Address              string `gorm:"size:255;unique;not null"`
+	Balance              Amount
+	LastNotifiedBalance  Amount
+	BalanceIncreasedAt   *time.Time
+	BalanceStalled       bool
 	LastPaymentTimestamp int64
+	PayoutLimit          Amount
+	MaxFeePrice          float64
+	PayoutNetwork        string
+	Stats                MinerStats    `gorm:"-"`
+	DailyEarnings        Amount        `gorm:"-"`
+	PayoutETA            time.Duration `gorm:"-"`
 }
 
 // NewMiner creates a Miner
@@ -32,6 +34,8 @@ func NewMiner(address string, coin string) (*Miner, error) {
 			return nil, err
 		}
 		miner.Coin = coin
+	} else if _, err := Coins.Get(coin); err != nil {
+		return nil, err
 	}
 	return miner, nil
 }
@@ -41,13 +45,11 @@ func (m *Miner) ParseCoin() (coin string, err error) {
 	if m.Address == "" {
 		return "", fmt.Errorf("Miner address is empty")
 	}
-	if len(m.Address) == CoinLengthETH && strings.HasPrefix(m.Address, "0x") {
-		return "eth", nil
-	}
-	if len(m.Address) == CoinLengthXCH && strings.HasPrefix(m.Address, "xch") {
-		return "xch", nil
+	registered, err := Coins.Deduce(m.Address)
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("Unsupported address")
+	return registered.Name, nil
 }
 
 // String represents Miner to a printable format
@@ -55,19 +57,112 @@ func (m *Miner) String() string {
 	return fmt.Sprintf("Miner<%s>", m.Address)
 }
 
+// BalanceAlertThreshold when the balance has crossed a configured threshold
+const BalanceAlertThreshold = "threshold"
+
+// BalanceAlertStalled when the balance has not increased for a configured duration
+const BalanceAlertStalled = "stalled"
+
+// BalanceAlert to store the reason of a balance alert
+type BalanceAlert struct {
+	Type       string
+	Threshold  float64
+	StalledFor time.Duration
+}
+
+// String represents BalanceAlert to a printable format
+func (b *BalanceAlert) String() string {
+	return fmt.Sprintf("BalanceAlert<%s>", b.Type)
+}
+
+// ExpectedWorker to store whether a worker expected by the configuration has been notified as missing
+type ExpectedWorker struct {
+	gorm.Model
+	MinerAddress string `gorm:"size:255;uniqueIndex:idx_expected_workers_miner_address_pattern;not null"`
+	Pattern      string `gorm:"size:255;uniqueIndex:idx_expected_workers_miner_address_pattern;not null"`
+	Missing      bool   `gorm:"not null"`
+}
+
+// String represents ExpectedWorker to a printable format
+func (e *ExpectedWorker) String() string {
+	return fmt.Sprintf("ExpectedWorker<%s>", e.Pattern)
+}
+
+// WorkerFlap to store the number of transitions of a flapping worker
+type WorkerFlap struct {
+	Transitions int
+	Window      time.Duration
+}
+
+// HashrateTypeEffective for hashrate computed by the pool from submitted shares
+const HashrateTypeEffective = "effective"
+
+// HashrateTypeReported for hashrate reported by the mining software
+const HashrateTypeReported = "reported"
+
+// HashrateDrop to store a hashrate drop of a worker compared to its rolling average
+type HashrateDrop struct {
+	Type    string
+	Current float64
+	Average float64
+	Drop    float64
+}
+
+// String represents HashrateDrop to a printable format
+func (h *HashrateDrop) String() string {
+	return fmt.Sprintf("HashrateDrop<%s, %.0f%%>", h.Type, h.Drop)
+}
+
+// ShareTypeStale for shares submitted too late
+const ShareTypeStale = "stale"
+
+// ShareTypeInvalid for shares rejected by the pool
+const ShareTypeInvalid = "invalid"
+
+// ShareRatio to store the ratio of stale or invalid shares of a worker exceeding a threshold
+type ShareRatio struct {
+	Type      string
+	Ratio     float64
+	Threshold float64
+}
+
+// String represents ShareRatio to a printable format
+func (s *ShareRatio) String() string {
+	return fmt.Sprintf("ShareRatio<%s, %.2f%%>", s.Type, s.Ratio)
+}
+
+// SettingChange to store a payout setting of a miner that has changed
+type SettingChange struct {
+	Name     string
+	Previous string
+	Current  string
+}
+
+// String represents SettingChange to a printable format
+func (s *SettingChange) String() string {
+	return fmt.Sprintf("SettingChange<%s>", s.Name)
+}
+
 // Payment to store payment attributes
 type Payment struct {
-	Hash      string
-	Value     float64
-	Timestamp int64
+	gorm.Model
+	MinerAddress string `gorm:"size:255;uniqueIndex:idx_payments_miner_address_hash;not null"`
+	Hash         string `gorm:"size:255;uniqueIndex:idx_payments_miner_address_hash;not null"`
+	Value        Amount `gorm:"not null;default:0"`
+	Fee          Amount
+	Timestamp    int64 `gorm:"index;not null"`
+	Confirmed    bool  `gorm:"not null"`
 }
 
 // NewPayment creates a Payment
-func NewPayment(hash string, value float64, timestamp int64) *Payment {
+func NewPayment(minerAddress string, hash string, value Amount, fee Amount, timestamp int64, confirmed bool) *Payment {
 	return &Payment{
-		Hash:      hash,
-		Value:     value,
-		Timestamp: timestamp,
+		MinerAddress: minerAddress,
+		Hash:         hash,
+		Value:        value,
+		Fee:          fee,
+		Timestamp:    timestamp,
+		Confirmed:    confirmed,
 	}
 }
 
@@ -79,19 +174,40 @@ func (p *Payment) String() string {
 // Worker to store workers attributes
 type Worker struct {
 	gorm.Model
-	MinerAddress string    `gorm:"not null"`
-	Name         string    `gorm:"not null"`
-	IsOnline     bool      `gorm:"not null"`
-	LastSeen     time.Time `gorm:"not null"`
+	MinerAddress             string `gorm:"not null"`
+	Name                     string `gorm:"not null"`
+	IsOnline                 bool   `gorm:"not null"`
+	LastSeen                 *time.Time
+	ReportedHashrate         float64
+	EffectiveHashrate        float64
+	AverageEffectiveHashrate float64
+	ValidShares              uint64
+	StaleShares              uint64
+	InvalidShares            uint64
+	HashrateDropped          bool
+	StaleRatioExceeded       bool
+	InvalidRatioExceeded     bool
+	StateChangedAt           *time.Time
+	NotifiedOnline           bool
+	Flapping                 bool
+	OfflineAt                *time.Time
+	Missing                  bool
+	Downtime                 time.Duration `gorm:"-"`
 }
 
 // NewWorker creates a Worker
-func NewWorker(minerAddress string, name string, isOnline bool, lastSeen time.Time) *Worker {
+func NewWorker(minerAddress string, name string, isOnline bool, lastSeen time.Time, reportedHashrate float64, effectiveHashrate float64, averageEffectiveHashrate float64, validShares uint64, staleShares uint64, invalidShares uint64) *Worker {
 	return &Worker{
-		MinerAddress: minerAddress,
-		Name:         name,
-		IsOnline:     isOnline,
-		LastSeen:     lastSeen,
+		MinerAddress:             minerAddress,
+		Name:                     name,
+		IsOnline:                 isOnline,
+		LastSeen:                 &lastSeen,
+		ReportedHashrate:         reportedHashrate,
+		EffectiveHashrate:        effectiveHashrate,
+		AverageEffectiveHashrate: averageEffectiveHashrate,
+		ValidShares:              validShares,
+		StaleShares:              staleShares,
+		InvalidShares:            invalidShares,
 	}
 }
 
@@ -5,10 +5,13 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"strings"
 	"text/template"
+	"time"
+	"unicode/utf8"
 
 	telegram "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	log "github.com/sirupsen/logrus"
@@ -19,20 +22,51 @@ var templateFiles embed.FS
 
 // Attachment is used to attach objects to templates
 type Attachment struct {
-	Miner   Miner
-	Payment Payment
-	Pool    Pool
-	Block   Block
-	Worker  Worker
+	Miner          Miner
+	BalanceAlert   BalanceAlert
+	Payment        Payment
+	SettingChanges []SettingChange
+	Pool           Pool
+	PoolAlert      PoolAlert
+	Block          Block
+	Worker         Worker
+	WorkerFlap     WorkerFlap
+	HashrateDrop   HashrateDrop
+	ShareRatio     ShareRatio
+	Summary        Summary
+}
+
+// Event to store a notification waiting to be sent in a digest
+type Event struct {
+	Type       string
+	Message    string
+	Attachment Attachment
+	CreatedAt  time.Time
+}
+
+// Digest is used to attach events to the digest template
+type Digest struct {
+	Events []Event
 }
 
 // Notifier interface to define how to send all kind of notifications
 type Notifier interface {
-	NotifyBalance(miner Miner, difference float64) error
+	NotifyBalance(miner Miner) error
+	NotifyBalanceAlert(miner Miner, alert BalanceAlert) error
 	NotifyPayment(miner Miner, payment Payment) error
+	NotifyPayoutSettings(miner Miner, changes []SettingChange) error
+	NotifyPoolStats(pool Pool, alert PoolAlert) error
 	NotifyBlock(pool Pool, block Block) error
-	NotifyOfflineWorker(worker Worker) error
-	NotifyTest(client FlexpoolClient) error
+	NotifyMinerBlock(pool Pool, miner Miner, block Block) error
+	NotifyBlockStatus(pool Pool, block Block) error
+	NotifyOfflineWorker(miner Miner, worker Worker) error
+	NotifyFlappingWorker(miner Miner, worker Worker, flap WorkerFlap) error
+	NotifyMissingWorker(miner Miner, worker Worker) error
+	NotifyHashrateDrop(miner Miner, worker Worker, drop HashrateDrop) error
+	NotifyShareRatio(miner Miner, worker Worker, ratio ShareRatio) error
+	NotifyReport(summary Summary) error
+	NotifyTest(client FlexpoolClient) (bool, error)
+	Flush(force bool) error
 }
 
 // TelegramNotifier to send notifications using Telegram
@@ -42,10 +76,13 @@ type TelegramNotifier struct {
 	chatID         int64
 	channelName    string
 	configurations *NotificationsConfig
+	prices         PriceProvider
+	currency       string
+	events         []Event
 }
 
 // NewTelegramNotifier to create a TelegramNotifier
-func NewTelegramNotifier(config *TelegramConfig, configurations *NotificationsConfig) (*TelegramNotifier, error) {
+func NewTelegramNotifier(config *TelegramConfig, configurations *NotificationsConfig, prices PriceProvider, currency string) (*TelegramNotifier, error) {
 	bot, err := telegram.NewBotAPI(config.Token)
 	if err != nil {
 		return nil, err
@@ -57,6 +94,8 @@ func NewTelegramNotifier(config *TelegramConfig, configurations *NotificationsCo
 		chatID:         config.ChatID,
 		channelName:    config.ChannelName,
 		configurations: configurations,
+		prices:         prices,
+		currency:       currency,
 	}, nil
 }
 
@@ -79,6 +118,108 @@ func (t *TelegramNotifier) sendMessage(message string) error {
 	return nil
 }
 
+// notify to format a message and either send it or keep it for the next digest
+func (t *TelegramNotifier) notify(eventType string, templateName string, attachment Attachment) error {
+	message, err := t.formatMessage(templateName, attachment)
+	if err != nil {
+		return err
+	}
+	if t.configurations.Digest.Enable {
+		log.Debugf("Adding %s event to digest", eventType)
+		// Events accumulate while the digest cannot be sent
+		if len(t.events) >= MaxDigestEvents {
+			log.Warnf("Digest is full, dropping oldest %s event", t.events[0].Type)
+			t.events = t.events[1:]
+		}
+		t.events = append(t.events, Event{
+			Type:       eventType,
+			Message:    message,
+			Attachment: attachment,
+			CreatedAt:  time.Now(),
+		})
+		return nil
+	}
+	return t.sendMessage(message)
+}
+
+// Flush to send pending events in digest messages
+// Events are kept until the digest window has elapsed, unless force is true. They are split in several messages to fit
+// in the Telegram limit and removed once sent, so only unsent events are retried on failure.
+func (t *TelegramNotifier) Flush(force bool) error {
+	if len(t.events) == 0 {
+		return nil
+	}
+	window := t.configurations.Digest.Window
+	if !force && window > 0 && time.Since(t.events[0].CreatedAt) < window {
+		log.Debugf("Waiting for digest window to send %d event(s)", len(t.events))
+		return nil
+	}
+
+	templateName := "templates/digest.tmpl"
+	if t.configurations.Digest.Template != "" {
+		templateName = t.configurations.Digest.Template
+	}
+	for len(t.events) > 0 {
+		count, message, err := t.formatDigest(templateName)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			log.Warnf("Dropping %s event too long to be sent in a digest", t.events[0].Type)
+			t.events = t.events[1:]
+			continue
+		}
+		if err = t.sendMessage(message); err != nil {
+			return err
+		}
+		log.Infof("Digest notification sent for %d event(s)", count)
+		t.events = t.events[count:]
+	}
+	t.events = nil
+	return nil
+}
+
+// formatDigest formats the first pending events fitting in a single message and returns how many of them it contains
+func (t *TelegramNotifier) formatDigest(templateName string) (count int, message string, err error) {
+	for count < len(t.events) {
+		formatted, err := t.formatMessage(templateName, Digest{Events: t.events[:count+1]})
+		if err != nil {
+			return 0, "", err
+		}
+		if utf8.RuneCountInString(formatted) > MaxMessageLength {
+			break
+		}
+		count++
+		message = formatted
+	}
+	return count, message, nil
+}
+
+// fiat converts the smallest unit of a coin to the configured fiat currency
+// Returns 0 when prices are not configured or not available to keep the notification
+func (t *TelegramNotifier) fiat(coin string, value Amount) float64 {
+	if t.prices == nil {
+		return 0
+	}
+	converted, err := ConvertCurrency(coin, value)
+	if err != nil {
+		log.Warnf("Cannot convert %s value: %v", coin, err)
+		return 0
+	}
+	price, err := t.prices.Price(coin, t.currency)
+	if err != nil {
+		log.Warnf("Cannot fetch price: %v", err)
+		return 0
+	}
+	fiat, _ := new(big.Float).Mul(converted, big.NewFloat(price)).Float64()
+	return fiat
+}
+
+// fiatCurrency returns the configured fiat currency in upper case
+func (t *TelegramNotifier) fiatCurrency() string {
+	return strings.ToUpper(t.currency)
+}
+
 // formatMessage to create a message with a template file name (either embeded or on disk)
 func (t *TelegramNotifier) formatMessage(templateFileName string, attachment interface{}) (message string, err error) {
 	// Create template
@@ -87,8 +228,14 @@ func (t *TelegramNotifier) formatMessage(templateFileName string, attachment int
 		"upper":                strings.ToUpper,
 		"lower":                strings.ToLower,
 		"convertCurrency":      ConvertCurrency,
+		"coinName":             CoinName,
 		"formatBlockURL":       FormatBlockURL,
 		"formatTransactionURL": FormatTransactionURL,
+		"formatHashrate":       FormatHashrate,
+		"humanizeDuration":     HumanizeDuration,
+		"since":                time.Since,
+		"fiat":                 t.fiat,
+		"fiatCurrency":         t.fiatCurrency,
 	}
 	tmpl := template.New(templateName).Funcs(templateFunctions)
 
@@ -129,11 +276,7 @@ func (t *TelegramNotifier) NotifyBalance(miner Miner) (err error) {
 	if t.configurations.Balance.Template != "" {
 		templateName = t.configurations.Balance.Template
 	}
-	message, err := t.formatMessage(templateName, Attachment{Miner: miner})
-	if err != nil {
-		return err
-	}
-	return t.sendMessage(message)
+	return t.notify("balance", templateName, Attachment{Miner: miner})
 }
 
 // testNotifyBalance sends a fake balance notification
@@ -150,6 +293,35 @@ func (t *TelegramNotifier) testNotifyBalance(client FlexpoolClient) error {
 	return t.NotifyBalance(*randomMiner)
 }
 
+// NotifyBalanceAlert to format and send a notification when a balance alert has been triggered
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyBalanceAlert(miner Miner, alert BalanceAlert) error {
+	templateName := "templates/balance-alert.tmpl"
+	if t.configurations.BalanceAlert.Template != "" {
+		templateName = t.configurations.BalanceAlert.Template
+	}
+	return t.notify("balance-alert", templateName, Attachment{Miner: miner, BalanceAlert: alert})
+}
+
+// testNotifyBalanceAlert sends a fake balance threshold notification
+func (t *TelegramNotifier) testNotifyBalanceAlert(client FlexpoolClient) error {
+	log.Debug("Testing balance alert notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	threshold, err := ConvertCurrency(randomMiner.Coin, randomMiner.Balance)
+	if err != nil {
+		return err
+	}
+	value, _ := threshold.Float64()
+	return t.NotifyBalanceAlert(*randomMiner, BalanceAlert{Type: BalanceAlertThreshold, Threshold: value})
+}
+
 // NotifyPayment to format and send a notification when a new payment has been detected
 // Implements the Notifier interface
 func (t *TelegramNotifier) NotifyPayment(miner Miner, payment Payment) error {
@@ -157,11 +329,7 @@ func (t *TelegramNotifier) NotifyPayment(miner Miner, payment Payment) error {
 	if t.configurations.Payment.Template != "" {
 		templateName = t.configurations.Payment.Template
 	}
-	message, err := t.formatMessage(templateName, Attachment{Miner: miner, Payment: payment})
-	if err != nil {
-		return err
-	}
-	return t.sendMessage(message)
+	return t.notify("payment", templateName, Attachment{Miner: miner, Payment: payment})
 }
 
 // testNotifyPayment sends a fake payment notification
@@ -182,6 +350,64 @@ func (t *TelegramNotifier) testNotifyPayment(client FlexpoolClient) error {
 	return t.NotifyPayment(*randomMiner, *randomPayment)
 }
 
+// NotifyPayoutSettings to format and send a notification when payout settings of a miner have changed
+// Changes are security related so the message is sent immediately, even when digest is enabled
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyPayoutSettings(miner Miner, changes []SettingChange) error {
+	templateName := "templates/payout-settings.tmpl"
+	if t.configurations.PayoutSettings.Template != "" {
+		templateName = t.configurations.PayoutSettings.Template
+	}
+	message, err := t.formatMessage(templateName, Attachment{Miner: miner, SettingChanges: changes})
+	if err != nil {
+		return err
+	}
+	return t.sendMessage(message)
+}
+
+// testNotifyPayoutSettings sends a fake payout settings notification
+func (t *TelegramNotifier) testNotifyPayoutSettings(client FlexpoolClient) error {
+	log.Debug("Testing payout settings notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	details, err := client.MinerDetails(randomMiner.Coin, randomMiner.Address)
+	if err != nil {
+		return err
+	}
+	changes := []SettingChange{{Name: "network", Previous: "unknown", Current: details.Network}}
+	return t.NotifyPayoutSettings(*randomMiner, changes)
+}
+
+// NotifyPoolStats to format and send a notification when a pool statistic has crossed its threshold
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyPoolStats(pool Pool, alert PoolAlert) error {
+	templateName := "templates/pool-stats.tmpl"
+	if t.configurations.PoolStats.Template != "" {
+		templateName = t.configurations.PoolStats.Template
+	}
+	return t.notify("pool-stats", templateName, Attachment{Pool: pool, PoolAlert: alert})
+}
+
+// testNotifyPoolStats sends a fake pool effort notification
+func (t *TelegramNotifier) testNotifyPoolStats(client FlexpoolClient) error {
+	log.Debug("Testing pool stats notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	pool, err := client.PoolStats(randomPool.Coin)
+	if err != nil {
+		return err
+	}
+	return t.NotifyPoolStats(*pool, PoolAlert{Type: PoolAlertEffort, Value: pool.CurrentEffort * 100, Threshold: 300})
+}
+
 // NotifyBlock to format and send a notification when a new block has been detected
 // Implements the Notifier interface
 func (t *TelegramNotifier) NotifyBlock(pool Pool, block Block) error {
@@ -189,11 +415,7 @@ func (t *TelegramNotifier) NotifyBlock(pool Pool, block Block) error {
 	if t.configurations.Block.Template != "" {
 		templateName = t.configurations.Block.Template
 	}
-	message, err := t.formatMessage(templateName, Attachment{Pool: pool, Block: block})
-	if err != nil {
-		return err
-	}
-	return t.sendMessage(message)
+	return t.notify("block", templateName, Attachment{Pool: pool, Block: block})
 }
 
 // testNotifyBlock sends a random block notification
@@ -210,17 +432,65 @@ func (t *TelegramNotifier) testNotifyBlock(client FlexpoolClient) error {
 	return t.NotifyBlock(*randomPool, *randomBlock)
 }
 
+// NotifyMinerBlock to format and send a notification when a new block has been found by a configured miner
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyMinerBlock(pool Pool, miner Miner, block Block) error {
+	templateName := "templates/miner-block.tmpl"
+	if t.configurations.MinerBlock.Template != "" {
+		templateName = t.configurations.MinerBlock.Template
+	}
+	return t.notify("miner-block", templateName, Attachment{Pool: pool, Miner: miner, Block: block})
+}
+
+// testNotifyMinerBlock sends a random block notification attributed to its miner
+func (t *TelegramNotifier) testNotifyMinerBlock(client FlexpoolClient) error {
+	log.Debug("Testing miner block notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomBlock, err := client.LastPoolBlock(randomPool)
+	if err != nil {
+		return err
+	}
+	miner, err := NewMiner(randomBlock.MinerAddress, randomPool.Coin)
+	if err != nil {
+		return err
+	}
+	return t.NotifyMinerBlock(*randomPool, *miner, *randomBlock)
+}
+
+// NotifyBlockStatus to format and send a notification when an announced block has been confirmed or orphaned
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyBlockStatus(pool Pool, block Block) error {
+	templateName := "templates/block-status.tmpl"
+	if t.configurations.BlockStatus.Template != "" {
+		templateName = t.configurations.BlockStatus.Template
+	}
+	return t.notify("block-status", templateName, Attachment{Pool: pool, Block: block})
+}
+
+// testNotifyBlockStatus sends a random block status notification
+func (t *TelegramNotifier) testNotifyBlockStatus(client FlexpoolClient) error {
+	log.Debug("Testing block status notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomBlock, err := client.LastPoolBlock(randomPool)
+	if err != nil {
+		return err
+	}
+	return t.NotifyBlockStatus(*randomPool, *randomBlock)
+}
+
 // NotifyOfflineWorker sends a message when a worker is online or offline
-func (t *TelegramNotifier) NotifyOfflineWorker(worker Worker) error {
+func (t *TelegramNotifier) NotifyOfflineWorker(miner Miner, worker Worker) error {
 	templateName := "templates/offline-worker.tmpl"
 	if t.configurations.OfflineWorker.Template != "" {
 		templateName = t.configurations.OfflineWorker.Template
 	}
-	message, err := t.formatMessage(templateName, Attachment{Worker: worker})
-	if err != nil {
-		return err
-	}
-	return t.sendMessage(message)
+	return t.notify("offline-worker", templateName, Attachment{Miner: miner, Worker: worker})
 }
 
 // testNotifyOfflineWorker sends a fake worker offline notification
@@ -239,7 +509,140 @@ func (t *TelegramNotifier) testNotifyOfflineWorker(client FlexpoolClient) error
 		return err
 	}
 	log.Debugf("%s", randomWorker)
-	return t.NotifyOfflineWorker(*randomWorker)
+	return t.NotifyOfflineWorker(*randomMiner, *randomWorker)
+}
+
+// NotifyFlappingWorker sends a message when a worker goes online and offline repeatedly
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyFlappingWorker(miner Miner, worker Worker, flap WorkerFlap) error {
+	templateName := "templates/flapping-worker.tmpl"
+	if t.configurations.FlappingWorker.Template != "" {
+		templateName = t.configurations.FlappingWorker.Template
+	}
+	return t.notify("flapping-worker", templateName, Attachment{Miner: miner, Worker: worker, WorkerFlap: flap})
+}
+
+// testNotifyFlappingWorker sends a fake flapping worker notification
+func (t *TelegramNotifier) testNotifyFlappingWorker(client FlexpoolClient) error {
+	log.Debug("Testing flapping worker notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	randomWorker, err := client.RandomWorker(randomMiner)
+	if err != nil {
+		return err
+	}
+	return t.NotifyFlappingWorker(*randomMiner, *randomWorker, WorkerFlap{Transitions: 4, Window: FlapWindow})
+}
+
+// NotifyMissingWorker sends a message when a known worker is not returned by the API anymore
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyMissingWorker(miner Miner, worker Worker) error {
+	templateName := "templates/missing-worker.tmpl"
+	if t.configurations.MissingWorker.Template != "" {
+		templateName = t.configurations.MissingWorker.Template
+	}
+	return t.notify("missing-worker", templateName, Attachment{Miner: miner, Worker: worker})
+}
+
+// testNotifyMissingWorker sends a fake missing worker notification
+func (t *TelegramNotifier) testNotifyMissingWorker(client FlexpoolClient) error {
+	log.Debug("Testing missing worker notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	randomWorker, err := client.RandomWorker(randomMiner)
+	if err != nil {
+		return err
+	}
+	return t.NotifyMissingWorker(*randomMiner, *randomWorker)
+}
+
+// NotifyHashrateDrop sends a message when the hashrate of a worker has dropped below its rolling average
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyHashrateDrop(miner Miner, worker Worker, drop HashrateDrop) error {
+	templateName := "templates/hashrate-drop.tmpl"
+	if t.configurations.HashrateDrop.Template != "" {
+		templateName = t.configurations.HashrateDrop.Template
+	}
+	return t.notify("hashrate-drop", templateName, Attachment{Miner: miner, Worker: worker, HashrateDrop: drop})
+}
+
+// testNotifyHashrateDrop sends a fake hashrate drop notification
+func (t *TelegramNotifier) testNotifyHashrateDrop(client FlexpoolClient) error {
+	log.Debug("Testing hashrate drop notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	randomWorker, err := client.RandomWorker(randomMiner)
+	if err != nil {
+		return err
+	}
+	drop := HashrateDrop{
+		Type:    HashrateTypeEffective,
+		Current: randomWorker.EffectiveHashrate / 2,
+		Average: randomWorker.EffectiveHashrate,
+		Drop:    50,
+	}
+	return t.NotifyHashrateDrop(*randomMiner, *randomWorker, drop)
+}
+
+// NotifyShareRatio sends a message when the ratio of stale or invalid shares of a worker is too high
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyShareRatio(miner Miner, worker Worker, ratio ShareRatio) error {
+	templateName := "templates/share-ratio.tmpl"
+	if t.configurations.ShareRatio.Template != "" {
+		templateName = t.configurations.ShareRatio.Template
+	}
+	return t.notify("share-ratio", templateName, Attachment{Miner: miner, Worker: worker, ShareRatio: ratio})
+}
+
+// testNotifyShareRatio sends a fake stale shares notification
+func (t *TelegramNotifier) testNotifyShareRatio(client FlexpoolClient) error {
+	log.Debug("Testing share ratio notification")
+	randomPool, err := client.RandomPool()
+	if err != nil {
+		return err
+	}
+	randomMiner, err := client.RandomMiner(randomPool)
+	if err != nil {
+		return err
+	}
+	randomWorker, err := client.RandomWorker(randomMiner)
+	if err != nil {
+		return err
+	}
+	ratio := ShareRatio{Type: ShareTypeStale, Threshold: 5}
+	total := randomWorker.ValidShares + randomWorker.StaleShares + randomWorker.InvalidShares
+	if total > 0 {
+		ratio.Ratio = float64(randomWorker.StaleShares) / float64(total) * 100
+	}
+	return t.NotifyShareRatio(*randomMiner, *randomWorker, ratio)
+}
+
+// NotifyReport to format and send a scheduled report
+// Implements the Notifier interface
+func (t *TelegramNotifier) NotifyReport(summary Summary) error {
+	templateName := "templates/report.tmpl"
+	if t.configurations.Report.Template != "" {
+		templateName = t.configurations.Report.Template
+	}
+	return t.notify("report", templateName, Attachment{Summary: summary})
 }
 
 // NotifyTest sends fake notifications
@@ -252,6 +655,14 @@ func (t *TelegramNotifier) NotifyTest(client FlexpoolClient) (executed bool, err
 		}
 	}
 
+	if t.configurations.BalanceAlert.Test {
+		if err = t.testNotifyBalanceAlert(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
 	if t.configurations.Payment.Test {
 		if err = t.testNotifyPayment(client); err != nil {
 			return false, err
@@ -260,6 +671,22 @@ func (t *TelegramNotifier) NotifyTest(client FlexpoolClient) (executed bool, err
 		}
 	}
 
+	if t.configurations.PayoutSettings.Test {
+		if err = t.testNotifyPayoutSettings(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
+	if t.configurations.PoolStats.Test {
+		if err = t.testNotifyPoolStats(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
 	if t.configurations.Block.Test {
 		if err = t.testNotifyBlock(client); err != nil {
 			return false, err
@@ -268,6 +695,22 @@ func (t *TelegramNotifier) NotifyTest(client FlexpoolClient) (executed bool, err
 		}
 	}
 
+	if t.configurations.MinerBlock.Test {
+		if err = t.testNotifyMinerBlock(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
+	if t.configurations.BlockStatus.Test {
+		if err = t.testNotifyBlockStatus(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
 	if t.configurations.OfflineWorker.Test {
 		if err = t.testNotifyOfflineWorker(client); err != nil {
 			return false, err
@@ -275,5 +718,35 @@ func (t *TelegramNotifier) NotifyTest(client FlexpoolClient) (executed bool, err
 			executed = true
 		}
 	}
+	if t.configurations.FlappingWorker.Test {
+		if err = t.testNotifyFlappingWorker(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
+	if t.configurations.MissingWorker.Test {
+		if err = t.testNotifyMissingWorker(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+
+	if t.configurations.HashrateDrop.Test {
+		if err = t.testNotifyHashrateDrop(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
+	if t.configurations.ShareRatio.Test {
+		if err = t.testNotifyShareRatio(client); err != nil {
+			return false, err
+		} else {
+			executed = true
+		}
+	}
 	return executed, nil
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	telegram "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeTelegramAPI records sent messages and fails on demand
+type fakeTelegramAPI struct {
+	fail     bool
+	messages []string
+}
+
+func (f *fakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/getMe"):
+		fmt.Fprint(w, `{"ok": true, "result": {"id": 1, "is_bot": true, "username": "test"}}`)
+	case f.fail:
+		fmt.Fprint(w, `{"ok": false, "error_code": 500, "description": "Internal Server Error"}`)
+	case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		f.messages = append(f.messages, r.FormValue("text"))
+		fmt.Fprintf(w, `{"ok": true, "result": {"message_id": %d}}`, len(f.messages))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestNotifier creates a TelegramNotifier with digest enabled sending messages to a fake Telegram API
+// Events are formatted with a template printing the worker name
+func newTestNotifier(t *testing.T, api *fakeTelegramAPI) *TelegramNotifier {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot, err := telegram.NewBotAPIWithClient("token", &http.Client{Transport: &rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatalf("Cannot create bot: %v", err)
+	}
+	templateFile := filepath.Join(t.TempDir(), "event.tmpl")
+	if err = os.WriteFile(templateFile, []byte("{{ .Worker.Name }}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &TelegramNotifier{
+		bot:    bot,
+		chatID: 1,
+		configurations: &NotificationsConfig{
+			Digest:        DigestConfig{Enable: true},
+			OfflineWorker: NotificationConfig{Template: templateFile},
+		},
+	}
+}
+
+func TestFlushSplitsDigest(t *testing.T) {
+	api := &fakeTelegramAPI{}
+	notifier := newTestNotifier(t, api)
+	// Each event is about 500 characters long so they cannot fit in a single message
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("%03d-%s", i, strings.Repeat("x", 500))
+		if err := notifier.NotifyOfflineWorker(Miner{}, Worker{Name: name}); err != nil {
+			t.Fatalf("Cannot notify: %v", err)
+		}
+	}
+	if err := notifier.Flush(true); err != nil {
+		t.Fatalf("Cannot flush digest: %v", err)
+	}
+
+	if len(api.messages) < 2 {
+		t.Fatalf("Expected digest to be split, got %d message(s)", len(api.messages))
+	}
+	sent := strings.Join(api.messages, "\n")
+	for i := 0; i < 20; i++ {
+		if !strings.Contains(sent, fmt.Sprintf("%03d-", i)) {
+			t.Errorf("Expected event %d to be sent", i)
+		}
+	}
+	for _, message := range api.messages {
+		if length := utf8.RuneCountInString(message); length > MaxMessageLength {
+			t.Errorf("Expected message shorter than %d characters, got %d", MaxMessageLength, length)
+		}
+	}
+	if len(notifier.events) != 0 {
+		t.Errorf("Expected no pending event, got %d", len(notifier.events))
+	}
+}
+
+func TestFlushKeepsUnsentEvents(t *testing.T) {
+	api := &fakeTelegramAPI{fail: true}
+	notifier := newTestNotifier(t, api)
+	for i := 0; i < MaxDigestEvents+10; i++ {
+		if err := notifier.NotifyOfflineWorker(Miner{}, Worker{Name: fmt.Sprintf("rig-%03d", i)}); err != nil {
+			t.Fatalf("Cannot notify: %v", err)
+		}
+	}
+	if err := notifier.Flush(true); err == nil {
+		t.Fatal("Expected flush to fail")
+	}
+	if len(notifier.events) != MaxDigestEvents {
+		t.Fatalf("Expected %d pending events, got %d", MaxDigestEvents, len(notifier.events))
+	}
+	// Oldest events are dropped
+	if name := notifier.events[0].Attachment.Worker.Name; name != "rig-010" {
+		t.Errorf("Expected oldest pending event to be rig-010, got %s", name)
+	}
+
+	api.fail = false
+	if err := notifier.Flush(true); err != nil {
+		t.Fatalf("Cannot flush digest: %v", err)
+	}
+	if len(api.messages) != 1 || len(notifier.events) != 0 {
+		t.Errorf("Expected pending events to be sent in one message, got %d message(s) and %d pending event(s)", len(api.messages), len(notifier.events))
+	}
+}
@@ -9,8 +9,12 @@ import (
 // Pool to store pool attributes
 type Pool struct {
 	gorm.Model
-	Coin            string `gorm:"unique;not null"`
-	LastBlockNumber uint64
+	Coin              string `gorm:"size:255;unique;not null"`
+	LastBlockNumber   uint64
+	Hashrate          float64
+	CurrentEffort     float64
+	AverageLuck       float64
+	NetworkDifficulty float64
 }
 
 // NewPool creates a Pool
@@ -23,19 +27,66 @@ func (p *Pool) String() string {
 	return fmt.Sprintf("Pool<%s>", p.Coin)
 }
 
+// PoolAlertEffort when the effort of the current round is above the threshold
+const PoolAlertEffort = "effort"
+
+// PoolAlertAverageLuck when the average luck is below the threshold
+const PoolAlertAverageLuck = "average-luck"
+
+// PoolAlertHashrate when the pool hashrate is below the threshold
+const PoolAlertHashrate = "hashrate"
+
+// PoolAlertNetworkDifficulty when the network difficulty is above the threshold
+const PoolAlertNetworkDifficulty = "network-difficulty"
+
+// PoolAlert to store a pool statistic that has crossed its threshold
+type PoolAlert struct {
+	Type      string
+	Value     float64
+	Threshold float64
+}
+
+// String represents PoolAlert to a printable format
+func (p *PoolAlert) String() string {
+	return fmt.Sprintf("PoolAlert<%s>", p.Type)
+}
+
+// BlockTypeBlock for blocks included in the main chain
+const BlockTypeBlock = "block"
+
+// BlockTypeUncle for uncle blocks
+const BlockTypeUncle = "uncle"
+
+// BlockTypeOrphan for blocks that have been orphaned
+const BlockTypeOrphan = "orphan"
+
 // Block to store block attributes
 type Block struct {
-	Hash   string  `gorm:"unique;not null"`
-	Number uint64  `gorm:"not null"`
-	Reward float64 `gorm:"not null"`
+	gorm.Model
+	Coin         string `gorm:"size:255;uniqueIndex:idx_blocks_coin_hash;not null"`
+	Hash         string `gorm:"size:255;uniqueIndex:idx_blocks_coin_hash;not null"`
+	Number       uint64 `gorm:"index;not null"`
+	Type         string `gorm:"not null"`
+	MinerAddress string `gorm:"size:255;index"`
+	Reward       Amount `gorm:"not null;default:0"`
+	Luck         float64
+	Confirmed    bool  `gorm:"not null"`
+	Timestamp    int64 `gorm:"index;not null"`
+	Notified     bool  `gorm:"not null"`
 }
 
 // NewBlock creates a Block
-func NewBlock(hash string, number uint64, reward float64) *Block {
+func NewBlock(coin string, hash string, number uint64, blockType string, minerAddress string, reward Amount, luck float64, confirmed bool, timestamp int64) *Block {
 	return &Block{
-		Hash:   hash,
-		Number: number,
-		Reward: reward,
+		Coin:         coin,
+		Hash:         hash,
+		Number:       number,
+		Type:         blockType,
+		MinerAddress: minerAddress,
+		Reward:       reward,
+		Luck:         luck,
+		Confirmed:    confirmed,
+		Timestamp:    timestamp,
 	}
 }
 
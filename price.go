@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CoinGeckoAPIURL constant to store CoinGecko API URL
+const CoinGeckoAPIURL = "https://api.coingecko.com/api/v3"
+
+// PriceCacheDuration to store how long a price is kept before requesting it again
+const PriceCacheDuration = 10 * time.Minute
+
+// PriceProvider to return the price of a coin in a fiat currency
+type PriceProvider interface {
+	Price(coin string, currency string) (float64, error)
+}
+
+// HistoricalPriceProvider to return the price of a coin in a fiat currency at a given date
+type HistoricalPriceProvider interface {
+	PriceAt(coin string, currency string, date time.Time) (float64, error)
+}
+
+// NewPriceProvider creates the PriceProvider configured for fiat conversions
+// Returns nil when no currency has been configured
+func NewPriceProvider(config *PricesConfig) (PriceProvider, error) {
+	if config.Currency == "" {
+		return nil, nil
+	}
+
+	var provider PriceProvider
+	switch config.Provider {
+	case "", "coingecko":
+		url := CoinGeckoAPIURL
+		if config.URL != "" {
+			url = config.URL
+		}
+		provider = NewCoinGeckoPriceProvider(url)
+	case "static":
+		provider = NewStaticPriceProvider(config.Static)
+	default:
+		return nil, fmt.Errorf("Unknown price provider %q (available: coingecko, static)", config.Provider)
+	}
+
+	cache := PriceCacheDuration
+	if config.Cache != 0 {
+		cache = config.Cache
+	}
+	return NewCachedPriceProvider(provider, cache), nil
+}
+
+// CoinGeckoPriceProvider to fetch prices from a CoinGecko compatible API
+type CoinGeckoPriceProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewCoinGeckoPriceProvider creates a CoinGeckoPriceProvider
+func NewCoinGeckoPriceProvider(url string) *CoinGeckoPriceProvider {
+	return &CoinGeckoPriceProvider{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: time.Second * 3},
+	}
+}
+
+// Price returns the price of a coin using the simple price route
+// Implements the PriceProvider interface
+func (c *CoinGeckoPriceProvider) Price(coin string, currency string) (float64, error) {
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return 0, err
+	}
+	id := registered.PriceID
+	if id == "" {
+		return 0, fmt.Errorf("Coin %s has no price identifier", coin)
+	}
+	currency = strings.ToLower(currency)
+
+	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s", c.url, id, currency)
+	log.Debugf("Requesting %s", url)
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Price API error: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var response map[string]map[string]float64
+	if err = json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("Cannot decode price response: %v", err)
+	}
+	price, ok := response[id][currency]
+	if !ok {
+		return 0, fmt.Errorf("Price of %s in %s not found", coin, strings.ToUpper(currency))
+	}
+	return price, nil
+}
+
+// PriceAt returns the price of a coin at the given date using the coin history route
+// Implements the HistoricalPriceProvider interface
+func (c *CoinGeckoPriceProvider) PriceAt(coin string, currency string, date time.Time) (float64, error) {
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return 0, err
+	}
+	id := registered.PriceID
+	if id == "" {
+		return 0, fmt.Errorf("Coin %s has no price identifier", coin)
+	}
+	currency = strings.ToLower(currency)
+
+	url := fmt.Sprintf("%s/coins/%s/history?date=%s&localization=false", c.url, id, date.UTC().Format("02-01-2006"))
+	log.Debugf("Requesting %s", url)
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Price API error: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("Cannot decode price response: %v", err)
+	}
+	price, ok := response.MarketData.CurrentPrice[currency]
+	if !ok {
+		return 0, fmt.Errorf("Price of %s in %s on %s not found", coin, strings.ToUpper(currency), date.UTC().Format("2006-01-02"))
+	}
+	return price, nil
+}
+
+// StaticPriceProvider to return prices defined in the configuration
+type StaticPriceProvider struct {
+	prices map[string]float64
+}
+
+// NewStaticPriceProvider creates a StaticPriceProvider
+func NewStaticPriceProvider(prices map[string]float64) *StaticPriceProvider {
+	return &StaticPriceProvider{prices: prices}
+}
+
+// Price returns the configured price of a coin, regardless of the currency
+// Implements the PriceProvider interface
+func (s *StaticPriceProvider) Price(coin string, currency string) (float64, error) {
+	price, ok := s.prices[coin]
+	if !ok {
+		return 0, fmt.Errorf("Price of %s not configured", coin)
+	}
+	return price, nil
+}
+
+// PriceAt returns the configured price of a coin, regardless of the currency and the date
+// Implements the HistoricalPriceProvider interface
+func (s *StaticPriceProvider) PriceAt(coin string, currency string, date time.Time) (float64, error) {
+	return s.Price(coin, currency)
+}
+
+// cachedPrice to store a price and when it has been fetched
+type cachedPrice struct {
+	value     float64
+	fetchedAt time.Time
+}
+
+// CachedPriceProvider to keep prices of another provider for a duration
+type CachedPriceProvider struct {
+	provider PriceProvider
+	duration time.Duration
+	prices   map[string]cachedPrice
+	history  map[string]float64
+}
+
+// NewCachedPriceProvider creates a CachedPriceProvider
+func NewCachedPriceProvider(provider PriceProvider, duration time.Duration) *CachedPriceProvider {
+	return &CachedPriceProvider{
+		provider: provider,
+		duration: duration,
+		prices:   make(map[string]cachedPrice),
+		history:  make(map[string]float64),
+	}
+}
+
+// Price returns the cached price or fetches it from the underlying provider when expired
+// Implements the PriceProvider interface
+func (c *CachedPriceProvider) Price(coin string, currency string) (float64, error) {
+	key := coin + "/" + strings.ToLower(currency)
+	if cached, ok := c.prices[key]; ok && time.Since(cached.fetchedAt) < c.duration {
+		return cached.value, nil
+	}
+	price, err := c.provider.Price(coin, currency)
+	if err != nil {
+		return 0, err
+	}
+	c.prices[key] = cachedPrice{value: price, fetchedAt: time.Now()}
+	return price, nil
+}
+
+// PriceAt returns the price of a coin at the given date from the underlying provider, prices are kept by day
+// Implements the HistoricalPriceProvider interface
+func (c *CachedPriceProvider) PriceAt(coin string, currency string, date time.Time) (float64, error) {
+	historical, ok := c.provider.(HistoricalPriceProvider)
+	if !ok {
+		return 0, fmt.Errorf("Price provider does not support historical prices")
+	}
+	key := coin + "/" + strings.ToLower(currency) + "/" + date.UTC().Format("2006-01-02")
+	if price, ok := c.history[key]; ok {
+		return price, nil
+	}
+	price, err := historical.PriceAt(coin, currency, date)
+	if err != nil {
+		return 0, err
+	}
+	c.history[key] = price
+	return price, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCoinGeckoPriceProviderPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		currency string
+		expected float64
+		err      bool
+	}{
+		{"success", http.StatusOK, `{"ethereum": {"usd": 3000.5, "eur": 2500}}`, "USD", 3000.5, false},
+		{"missing currency", http.StatusOK, `{"ethereum": {"eur": 2500}}`, "usd", 0, true},
+		{"missing coin", http.StatusOK, `{}`, "usd", 0, true},
+		{"invalid body", http.StatusOK, `not json`, "usd", 0, true},
+		{"rate limited", http.StatusTooManyRequests, `{"status": {"error_code": 429}}`, "usd", 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/simple/price" || r.URL.Query().Get("ids") != "ethereum" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			price, err := NewCoinGeckoPriceProvider(server.URL+"/").Price("eth", tc.currency)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected error, got price %f", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if price != tc.expected {
+				t.Errorf("Expected price %f, got %f", tc.expected, price)
+			}
+		})
+	}
+}
+
+func TestCoinGeckoPriceProviderPriceAt(t *testing.T) {
+	date := time.Date(2021, 9, 5, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		currency string
+		expected float64
+		err      bool
+	}{
+		{"success", http.StatusOK, `{"id": "ethereum", "market_data": {"current_price": {"usd": 3900.25}}}`, "usd", 3900.25, false},
+		{"missing currency", http.StatusOK, `{"id": "ethereum", "market_data": {"current_price": {"eur": 3300}}}`, "usd", 0, true},
+		{"missing market data", http.StatusOK, `{"id": "ethereum"}`, "usd", 0, true},
+		{"not found", http.StatusNotFound, `{"error": "coin not found"}`, "usd", 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/coins/ethereum/history" || r.URL.Query().Get("date") != "05-09-2021" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			price, err := NewCoinGeckoPriceProvider(server.URL).PriceAt("eth", tc.currency, date)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected error, got price %f", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if price != tc.expected {
+				t.Errorf("Expected price %f, got %f", tc.expected, price)
+			}
+		})
+	}
+}
+
+func TestCoinGeckoPriceProviderUnknownCoin(t *testing.T) {
+	provider := NewCoinGeckoPriceProvider("http://127.0.0.1:0")
+	if _, err := provider.Price("unknown", "usd"); err == nil {
+		t.Error("Expected error for unknown coin")
+	}
+	if _, err := provider.PriceAt("unknown", "usd", time.Now()); err == nil {
+		t.Error("Expected error for unknown coin")
+	}
+}
+
+func TestStaticPriceProvider(t *testing.T) {
+	provider := NewStaticPriceProvider(map[string]float64{"eth": 3000})
+
+	price, err := provider.Price("eth", "usd")
+	if err != nil || price != 3000 {
+		t.Errorf("Expected price 3000, got %f (%v)", price, err)
+	}
+	price, err = provider.PriceAt("eth", "eur", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || price != 3000 {
+		t.Errorf("Expected historical price 3000, got %f (%v)", price, err)
+	}
+	if _, err = provider.Price("etc", "usd"); err == nil {
+		t.Error("Expected error for coin without price")
+	}
+}
+
+func TestNewPriceProvider(t *testing.T) {
+	provider, err := NewPriceProvider(&PricesConfig{})
+	if err != nil || provider != nil {
+		t.Errorf("Expected no provider without currency, got %v (%v)", provider, err)
+	}
+	if _, err = NewPriceProvider(&PricesConfig{Currency: "usd", Provider: "unknown"}); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+	provider, err = NewPriceProvider(&PricesConfig{Currency: "usd", Provider: "static", Static: map[string]float64{"eth": 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := provider.(*CachedPriceProvider); !ok {
+		t.Errorf("Expected cached provider, got %T", provider)
+	}
+}
+
+// countingPriceProvider returns an incremented price on each request
+type countingPriceProvider struct {
+	requests int
+}
+
+func (c *countingPriceProvider) Price(coin string, currency string) (float64, error) {
+	c.requests++
+	return float64(c.requests), nil
+}
+
+func (c *countingPriceProvider) PriceAt(coin string, currency string, date time.Time) (float64, error) {
+	c.requests++
+	return float64(c.requests), nil
+}
+
+func TestCachedPriceProviderPrice(t *testing.T) {
+	counting := &countingPriceProvider{}
+	provider := NewCachedPriceProvider(counting, time.Hour)
+
+	first, _ := provider.Price("eth", "USD")
+	second, _ := provider.Price("eth", "usd")
+	if first != 1 || second != 1 || counting.requests != 1 {
+		t.Errorf("Expected cached price, got %f then %f after %d requests", first, second, counting.requests)
+	}
+
+	// Expire the cached price
+	cached := provider.prices["eth/usd"]
+	cached.fetchedAt = time.Now().Add(-2 * time.Hour)
+	provider.prices["eth/usd"] = cached
+	if price, _ := provider.Price("eth", "usd"); price != 2 {
+		t.Errorf("Expected price to be fetched again after expiry, got %f", price)
+	}
+
+	if price, _ := provider.Price("etc", "usd"); price != 3 {
+		t.Errorf("Expected prices to be cached per coin, got %f", price)
+	}
+}
+
+func TestCachedPriceProviderPriceAt(t *testing.T) {
+	counting := &countingPriceProvider{}
+	provider := NewCachedPriceProvider(counting, time.Hour)
+
+	morning := time.Date(2021, 9, 5, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2021, 9, 5, 20, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2021, 9, 6, 8, 0, 0, 0, time.UTC)
+
+	first, _ := provider.PriceAt("eth", "usd", morning)
+	second, _ := provider.PriceAt("eth", "USD", evening)
+	if first != 1 || second != 1 {
+		t.Errorf("Expected prices of the same day to be cached, got %f then %f", first, second)
+	}
+	if price, _ := provider.PriceAt("eth", "usd", nextDay); price != 2 {
+		t.Errorf("Expected price of another day to be fetched, got %f", price)
+	}
+	if price, _ := provider.PriceAt("eth", "eur", morning); price != 3 {
+		t.Errorf("Expected prices to be cached per currency, got %f", price)
+	}
+
+	// Historical prices never expire
+	provider.duration = 0
+	if price, _ := provider.PriceAt("eth", "usd", morning); price != 1 {
+		t.Errorf("Expected historical price to be kept, got %f", price)
+	}
+}
+
+// currentPriceProvider only supports current prices
+type currentPriceProvider struct{}
+
+func (currentPriceProvider) Price(coin string, currency string) (float64, error) {
+	return 1, nil
+}
+
+func TestCachedPriceProviderWithoutHistory(t *testing.T) {
+	provider := NewCachedPriceProvider(currentPriceProvider{}, time.Hour)
+	if _, err := provider.PriceAt("eth", "usd", time.Now()); err == nil {
+		t.Error("Expected error for provider without historical prices")
+	}
+}
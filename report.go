@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Report to store the last time a scheduled report has been sent
+type Report struct {
+	gorm.Model
+	Name      string `gorm:"size:255;unique;not null"`
+	LastRunAt *time.Time
+}
+
+// String represents Report to a printable format
+func (r *Report) String() string {
+	return fmt.Sprintf("Report<%s>", r.Name)
+}
+
+// Summary to store the activity of miners over a period
+type Summary struct {
+	Name   string
+	Start  time.Time
+	End    time.Time
+	Miners []MinerSummary
+}
+
+// MinerSummary to store the activity of a single miner over a period
+type MinerSummary struct {
+	Miner                   Miner
+	BalanceDelta            Amount
+	Payments                []Payment
+	PaymentsTotal           Amount
+	Earnings                Amount
+	Blocks                  int64
+	Uptime                  float64
+	AverageHashrate         float64
+	AverageReportedHashrate float64
+}
+
+// handleReport sends a report when its schedule is due
+func (a *Assistant) handleReport(configuredReport ReportConfig) error {
+	schedule, err := ParseSchedule(configuredReport.Schedule)
+	if err != nil {
+		return fmt.Errorf("Could not parse schedule of report %s: %v", configuredReport.Name, err)
+	}
+
+	var dbReport Report
+	trx := a.db.Where(Report{Name: configuredReport.Name}).Attrs(Report{Name: configuredReport.Name}).FirstOrCreate(&dbReport)
+	if trx.Error != nil {
+		return fmt.Errorf("Cannot fetch report %s from database: %v", configuredReport.Name, trx.Error)
+	}
+
+	now := time.Now()
+
+	// Report has never been scheduled, start the first period now
+	if dbReport.LastRunAt == nil {
+		dbReport.LastRunAt = &now
+		if trx = a.db.Save(&dbReport); trx.Error != nil {
+			return fmt.Errorf("Cannot update report: %v", trx.Error)
+		}
+		return nil
+	}
+
+	next, err := schedule.Next(*dbReport.LastRunAt)
+	if err != nil {
+		return err
+	}
+	if next.After(now) {
+		log.Debugf("%s is scheduled at %s", &dbReport, next)
+		return nil
+	}
+
+	summary := a.summarize(configuredReport.Name, *dbReport.LastRunAt, now)
+	if err = a.notifier.NotifyReport(summary); err != nil {
+		return fmt.Errorf("Cannot send notification: %v", err)
+	}
+	log.Infof("Report notification sent for %s", &dbReport)
+
+	dbReport.LastRunAt = &now
+	if trx = a.db.Save(&dbReport); trx.Error != nil {
+		return fmt.Errorf("Cannot update report: %v", trx.Error)
+	}
+	return nil
+}
+
+// summarize creates a Summary of all configured miners between start and end
+func (a *Assistant) summarize(name string, start time.Time, end time.Time) Summary {
+	summary := Summary{Name: name, Start: start, End: end}
+	blocksByCoin := make(map[string]int64)
+
+	for _, configuredMiner := range a.config.Miners {
+		miner, err := NewMiner(configuredMiner.Address, configuredMiner.Coin)
+		if err != nil {
+			log.Warnf("Could not parse miner: %v", err)
+			continue
+		}
+
+		if _, ok := blocksByCoin[miner.Coin]; !ok {
+			blocks, err := a.countBlocks(miner.Coin, start, end)
+			if err != nil {
+				log.Warnf("Could not count blocks: %v", err)
+			}
+			blocksByCoin[miner.Coin] = blocks
+		}
+
+		minerSummary, err := a.summarizeMiner(miner, start, end)
+		if err != nil {
+			log.Warnf("Could not summarize %s: %v", miner, err)
+			continue
+		}
+		minerSummary.Blocks = blocksByCoin[miner.Coin]
+		summary.Miners = append(summary.Miners, *minerSummary)
+	}
+	return summary
+}
+
+// summarizeMiner computes balance, payments and workers activity of a miner between start and end
+func (a *Assistant) summarizeMiner(miner *Miner, start time.Time, end time.Time) (*MinerSummary, error) {
+	minerSummary := &MinerSummary{Miner: *miner}
+	period := a.db.Where("miner_address = ? AND created_at >= ? AND created_at < ?", miner.Address, start, end)
+
+	// Balance
+	var first, last BalanceRecord
+	if trx := period.Session(&gorm.Session{}).Order("created_at").Limit(1).Find(&first); trx.Error != nil {
+		return nil, trx.Error
+	}
+	if trx := period.Session(&gorm.Session{}).Order("created_at desc").Limit(1).Find(&last); trx.Error != nil {
+		return nil, trx.Error
+	}
+	minerSummary.Miner.Balance = last.Value
+	minerSummary.BalanceDelta = last.Value.Sub(first.Value)
+
+	// Payments
+	var payments []Payment
+	trx := a.db.Where("miner_address = ? AND timestamp >= ? AND timestamp < ?", miner.Address, start.Unix(), end.Unix()).Order("timestamp").Find(&payments)
+	if trx.Error != nil {
+		return nil, trx.Error
+	}
+	for _, payment := range payments {
+		minerSummary.PaymentsTotal = minerSummary.PaymentsTotal.Add(payment.Value)
+	}
+	minerSummary.Payments = payments
+	minerSummary.Earnings = minerSummary.BalanceDelta.Add(minerSummary.PaymentsTotal)
+
+	// Workers
+	var records []WorkerRecord
+	if trx := period.Session(&gorm.Session{}).Find(&records); trx.Error != nil {
+		return nil, trx.Error
+	}
+	if len(records) > 0 {
+		online := 0
+		hashrates := make(map[string]float64)
+		observations := make(map[string]int)
+		for _, record := range records {
+			if record.IsOnline {
+				online++
+			}
+			hashrates[record.Name] += record.EffectiveHashrate
+			observations[record.Name]++
+		}
+		minerSummary.Uptime = float64(online) / float64(len(records)) * 100
+		for name, hashrate := range hashrates {
+			minerSummary.AverageHashrate += hashrate / float64(observations[name])
+		}
+	}
+
+	// Stats are more accurate than workers for hashrates
+	var stats []MinerStats
+	if trx := period.Session(&gorm.Session{}).Order("created_at").Find(&stats); trx.Error != nil {
+		return nil, trx.Error
+	}
+	if len(stats) > 0 {
+		var effective, reported float64
+		for _, stat := range stats {
+			effective += stat.EffectiveHashrate
+			reported += stat.ReportedHashrate
+		}
+		minerSummary.AverageHashrate = effective / float64(len(stats))
+		minerSummary.AverageReportedHashrate = reported / float64(len(stats))
+		minerSummary.Miner.Stats = stats[len(stats)-1]
+	}
+
+	return minerSummary, nil
+}
+
+// countBlocks returns the number of blocks found by the pool between start and end
+func (a *Assistant) countBlocks(coin string, start time.Time, end time.Time) (count int64, err error) {
+	trx := a.db.Model(&Block{}).Where("coin = ? AND type != ? AND timestamp >= ? AND timestamp < ?", coin, BlockTypeOrphan, start.Unix(), end.Unix()).Count(&count)
+	return count, trx.Error
+}
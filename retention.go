@@ -0,0 +1,290 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// EnsureDatabaseRetention removes stale objects and expired history from database then downsamples old history
+func EnsureDatabaseRetention(db *gorm.DB, config *RetentionConfig) error {
+	now := time.Now()
+	if config.Workers > 0 {
+		log.Debugf("Deleting workers inactive for %s", config.Workers)
+		var worker *Worker
+		// Workers created before the last seen time was nullable may have been upgraded without it
+		cutoff := now.Add(-config.Workers)
+		trx := db.Unscoped().Where("last_seen < ? OR (last_seen IS NULL AND updated_at < ?)", cutoff, cutoff).Delete(&worker)
+		if trx.Error != nil {
+			return trx.Error
+		}
+	}
+
+	policies := []struct {
+		name      string
+		model     interface{}
+		retention time.Duration
+		condition string
+		cutoff    interface{}
+	}{
+		{"balance history", &BalanceRecord{}, config.BalanceHistory, "created_at < ?", now.Add(-config.BalanceHistory)},
+		{"worker history", &WorkerRecord{}, config.WorkerHistory, "created_at < ?", now.Add(-config.WorkerHistory)},
+		{"miner stats", &MinerStats{}, config.MinerStats, "created_at < ?", now.Add(-config.MinerStats)},
+		{"payments", &Payment{}, config.Payments, "timestamp < ?", now.Add(-config.Payments).Unix()},
+		{"blocks", &Block{}, config.Blocks, "timestamp < ?", now.Add(-config.Blocks).Unix()},
+	}
+	for _, policy := range policies {
+		if policy.retention <= 0 {
+			continue
+		}
+		trx := db.Unscoped().Where(policy.condition, policy.cutoff).Delete(policy.model)
+		if trx.Error != nil {
+			return trx.Error
+		}
+		log.Debugf("Deleted %d %s records older than %s", trx.RowsAffected, policy.name, policy.retention)
+	}
+
+	return downsampleHistory(db, config, now)
+}
+
+// downsampleHistory aggregates history per hour after HourlyAfter then per day after DailyAfter
+// Periods are aligned on hours and days so each aggregate covers a complete period
+func downsampleHistory(db *gorm.DB, config *RetentionConfig, now time.Time) error {
+	var hourlyStart time.Time
+	if config.DailyAfter > 0 {
+		hourlyStart = now.Add(-config.DailyAfter).Truncate(24 * time.Hour)
+	}
+	if config.HourlyAfter > 0 {
+		hourlyEnd := now.Add(-config.HourlyAfter).Truncate(time.Hour)
+		if hourlyEnd.After(hourlyStart) {
+			if err := downsample(db, hourlyStart, hourlyEnd, time.Hour); err != nil {
+				return err
+			}
+		}
+	}
+	if config.DailyAfter > 0 {
+		if err := downsample(db, time.Time{}, hourlyStart, 24*time.Hour); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downsample aggregates history created between start and end per resolution
+// A zero start time includes all records before end
+func downsample(db *gorm.DB, start time.Time, end time.Time, resolution time.Duration) error {
+	log.Debugf("Downsampling history before %s per %s", end.Format(time.RFC3339), resolution)
+	steps := []func(tx *gorm.DB, period *gorm.DB, resolution time.Duration) error{
+		downsampleBalanceRecords,
+		downsampleWorkerRecords,
+		downsampleMinerStats,
+	}
+	for _, step := range steps {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			period := tx.Where("created_at < ?", end)
+			if !start.IsZero() {
+				period = period.Where("created_at >= ?", start)
+			}
+			return step(tx, period, resolution)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downsampleBalanceRecords keeps the last balance of each period
+func downsampleBalanceRecords(tx *gorm.DB, period *gorm.DB, resolution time.Duration) error {
+	groups := newPeriodGroups(resolution, func(group []interface{}) error {
+		first := group[0].(BalanceRecord)
+		last := group[len(group)-1].(BalanceRecord)
+		first.Value = last.Value
+		first.CreatedAt = last.CreatedAt
+		var ids []uint
+		for _, record := range group[1:] {
+			ids = append(ids, record.(BalanceRecord).ID)
+		}
+		return replaceRecords(tx, &first, &BalanceRecord{}, ids)
+	})
+	var records []BalanceRecord
+	trx := period.FindInBatches(&records, DownsamplingBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, record := range records {
+			if err := groups.add(record.MinerAddress, record.CreatedAt, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if trx.Error != nil {
+		return trx.Error
+	}
+	return groups.close()
+}
+
+// downsampleWorkerRecords averages hashrates and keeps the last shares of each worker per period
+// A worker is online during the period when it has been seen online at least half of the time
+func downsampleWorkerRecords(tx *gorm.DB, period *gorm.DB, resolution time.Duration) error {
+	groups := newPeriodGroups(resolution, func(group []interface{}) error {
+		aggregate := group[0].(WorkerRecord)
+		last := group[len(group)-1].(WorkerRecord)
+		aggregate.CreatedAt = last.CreatedAt
+		// Shares are rolling counters, the last record of the period holds the most recent ones
+		aggregate.ValidShares = last.ValidShares
+		aggregate.StaleShares = last.StaleShares
+		aggregate.InvalidShares = last.InvalidShares
+		online := 0
+		var reported, effective float64
+		var ids []uint
+		for i, item := range group {
+			record := item.(WorkerRecord)
+			if record.IsOnline {
+				online++
+			}
+			reported += record.ReportedHashrate
+			effective += record.EffectiveHashrate
+			if i > 0 {
+				ids = append(ids, record.ID)
+			}
+		}
+		count := len(group)
+		aggregate.IsOnline = online*2 >= count
+		aggregate.ReportedHashrate = reported / float64(count)
+		aggregate.EffectiveHashrate = effective / float64(count)
+		return replaceRecords(tx, &aggregate, &WorkerRecord{}, ids)
+	})
+	var records []WorkerRecord
+	trx := period.FindInBatches(&records, DownsamplingBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, record := range records {
+			if err := groups.add(record.MinerAddress+"/"+record.Name, record.CreatedAt, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if trx.Error != nil {
+		return trx.Error
+	}
+	return groups.close()
+}
+
+// downsampleMinerStats averages hashrates and keeps the last shares of each miner per period
+func downsampleMinerStats(tx *gorm.DB, period *gorm.DB, resolution time.Duration) error {
+	groups := newPeriodGroups(resolution, func(group []interface{}) error {
+		aggregate := group[0].(MinerStats)
+		last := group[len(group)-1].(MinerStats)
+		aggregate.CreatedAt = last.CreatedAt
+		aggregate.ValidShares = last.ValidShares
+		aggregate.StaleShares = last.StaleShares
+		aggregate.InvalidShares = last.InvalidShares
+		var effective, averageEffective, reported float64
+		var ids []uint
+		for i, item := range group {
+			record := item.(MinerStats)
+			effective += record.EffectiveHashrate
+			averageEffective += record.AverageEffectiveHashrate
+			reported += record.ReportedHashrate
+			if i > 0 {
+				ids = append(ids, record.ID)
+			}
+		}
+		count := float64(len(group))
+		aggregate.EffectiveHashrate = effective / count
+		aggregate.AverageEffectiveHashrate = averageEffective / count
+		aggregate.ReportedHashrate = reported / count
+		return replaceRecords(tx, &aggregate, &MinerStats{}, ids)
+	})
+	var records []MinerStats
+	trx := period.FindInBatches(&records, DownsamplingBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, record := range records {
+			if err := groups.add(record.MinerAddress, record.CreatedAt, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if trx.Error != nil {
+		return trx.Error
+	}
+	return groups.close()
+}
+
+// periodGroup to store records of a key created during the same period
+type periodGroup struct {
+	bucket  time.Time
+	records []interface{}
+}
+
+// periodGroups to group records sharing the same key and period while they are read in batches
+// Records are read by primary key, which follows creation time, so a group is complete once a record of the same key
+// belongs to a later period. Aggregates keep the creation time of the last record to stay in their period.
+type periodGroups struct {
+	resolution time.Duration
+	aggregate  func(group []interface{}) error
+	groups     map[string]*periodGroup
+}
+
+// newPeriodGroups creates a periodGroups
+func newPeriodGroups(resolution time.Duration, aggregate func(group []interface{}) error) *periodGroups {
+	return &periodGroups{
+		resolution: resolution,
+		aggregate:  aggregate,
+		groups:     make(map[string]*periodGroup),
+	}
+}
+
+// add appends a record to the group of its key and aggregates the previous group when the period changes
+func (p *periodGroups) add(key string, createdAt time.Time, record interface{}) error {
+	bucket := createdAt.Truncate(p.resolution)
+	group, ok := p.groups[key]
+	if ok && group.bucket.Equal(bucket) {
+		group.records = append(group.records, record)
+		return nil
+	}
+	if ok {
+		if err := p.flush(group); err != nil {
+			return err
+		}
+	}
+	p.groups[key] = &periodGroup{bucket: bucket, records: []interface{}{record}}
+	return nil
+}
+
+// close aggregates the remaining groups
+func (p *periodGroups) close() error {
+	for key, group := range p.groups {
+		if err := p.flush(group); err != nil {
+			return err
+		}
+		delete(p.groups, key)
+	}
+	return nil
+}
+
+// flush aggregates a group, periods containing a single record are already aggregated
+func (p *periodGroups) flush(group *periodGroup) error {
+	if len(group.records) < 2 {
+		return nil
+	}
+	return p.aggregate(group.records)
+}
+
+// replaceRecords saves an aggregated record and deletes the records it replaces
+func replaceRecords(tx *gorm.DB, record interface{}, model interface{}, ids []uint) error {
+	if trx := tx.Save(record); trx.Error != nil {
+		return trx.Error
+	}
+	return tx.Delete(model, ids).Error
+}
+
+// VacuumDatabase rebuilds a SQLite database file to reclaim space of deleted records
+// Other databases reclaim space with their own maintenance
+func VacuumDatabase(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		log.Debugf("Skipping vacuum of %s database", db.Dialector.Name())
+		return nil
+	}
+	log.Info("Vacuuming database")
+	return db.Exec("VACUUM").Error
+}
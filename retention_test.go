@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnsureDatabaseRetentionWorkers(t *testing.T) {
+	db := newTestDatabase(t)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	old := now.Add(-48 * time.Hour)
+	workers := []Worker{
+		{MinerAddress: testAddress, Name: "seen-recently", LastSeen: &recent},
+		{MinerAddress: testAddress, Name: "seen-long-ago", LastSeen: &old},
+		// Workers upgraded from a release without last seen time
+		{MinerAddress: testAddress, Name: "unseen-updated-recently"},
+		{MinerAddress: testAddress, Name: "unseen-updated-long-ago"},
+	}
+	workers[3].UpdatedAt = old
+	if trx := db.Create(&workers); trx.Error != nil {
+		t.Fatalf("Cannot create workers: %v", trx.Error)
+	}
+
+	if err := EnsureDatabaseRetention(db, &RetentionConfig{Workers: 24 * time.Hour}); err != nil {
+		t.Fatalf("Cannot ensure retention: %v", err)
+	}
+	var names []string
+	if trx := db.Unscoped().Model(&Worker{}).Order("name").Pluck("name", &names); trx.Error != nil {
+		t.Fatalf("Cannot fetch workers: %v", trx.Error)
+	}
+	expected := []string{"seen-recently", "unseen-updated-recently"}
+	if len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] {
+		t.Errorf("Expected workers %v, got %v", expected, names)
+	}
+}
+
+func TestDownsampleBalanceRecords(t *testing.T) {
+	db := newTestDatabase(t)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Cannot migrate database: %v", err)
+	}
+	start := time.Date(2021, 9, 5, 0, 0, 0, 0, time.UTC)
+	other := "0x0000000000000000000000000000000000000002"
+
+	// More records than a batch, interleaved between two miners
+	var records []BalanceRecord
+	for i := 0; i < DownsamplingBatchSize+500; i++ {
+		createdAt := start.Add(time.Duration(i) * time.Minute)
+		records = append(records, BalanceRecord{MinerAddress: testAddress, Value: NewAmount(int64(i)), CreatedAt: createdAt})
+		if i%30 == 0 {
+			records = append(records, BalanceRecord{MinerAddress: other, Value: NewAmount(int64(i)), CreatedAt: createdAt})
+		}
+	}
+	if trx := db.CreateInBatches(&records, 100); trx.Error != nil {
+		t.Fatalf("Cannot create records: %v", trx.Error)
+	}
+
+	end := start.Add(24 * time.Hour)
+	if err := downsample(db, time.Time{}, end, time.Hour); err != nil {
+		t.Fatalf("Cannot downsample: %v", err)
+	}
+
+	var downsampled []BalanceRecord
+	if trx := db.Where("miner_address = ?", testAddress).Order("created_at").Find(&downsampled); trx.Error != nil {
+		t.Fatalf("Cannot fetch records: %v", trx.Error)
+	}
+	// 24 hourly aggregates followed by the records after the end
+	expectedCount := 24 + DownsamplingBatchSize + 500 - 24*60
+	if len(downsampled) != expectedCount {
+		t.Fatalf("Expected %d records, got %d", expectedCount, len(downsampled))
+	}
+	for hour := 0; hour < 24; hour++ {
+		record := downsampled[hour]
+		last := start.Add(time.Duration(hour*60+59) * time.Minute)
+		if !record.CreatedAt.Equal(last) {
+			t.Errorf("Expected aggregate of hour %d at %s, got %s", hour, last, record.CreatedAt)
+		}
+		if expected := NewAmount(int64(hour*60 + 59)); record.Value.Cmp(expected) != 0 {
+			t.Errorf("Expected last balance %s for hour %d, got %s", expected, hour, record.Value)
+		}
+	}
+
+	var otherCount int64
+	if trx := db.Model(&BalanceRecord{}).Where("miner_address = ?", other).Count(&otherCount); trx.Error != nil {
+		t.Fatalf("Cannot count records: %v", trx.Error)
+	}
+	if otherCount != 24+2 {
+		t.Errorf("Expected 26 records of the other miner, got %d", otherCount)
+	}
+
+	// Aggregates stay in their period so downsampling again per day merges them without moving them to the next day
+	if err := downsample(db, time.Time{}, end, 24*time.Hour); err != nil {
+		t.Fatalf("Cannot downsample: %v", err)
+	}
+	var daily BalanceRecord
+	if trx := db.Where("miner_address = ? AND created_at < ?", testAddress, end).Find(&daily); trx.Error != nil || trx.RowsAffected != 1 {
+		t.Fatalf("Expected one daily record, got %d (%v)", trx.RowsAffected, trx.Error)
+	}
+	if last := end.Add(-time.Minute); !daily.CreatedAt.Equal(last) || daily.Value.Cmp(NewAmount(24*60-1)) != 0 {
+		t.Errorf("Expected daily balance %d at %s, got %s at %s", 24*60-1, last, daily.Value, daily.CreatedAt)
+	}
+}
+
+func TestDownsampleWorkerRecords(t *testing.T) {
+	start := time.Date(2021, 9, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		online           []bool
+		expectedOnline   bool
+		expectedHashrate float64
+	}{
+		{"always online", []bool{true, true, true, true}, true, 15},
+		{"online half of the time", []bool{true, false, true, false}, true, 15},
+		{"mostly offline", []bool{false, false, true, false}, false, 15},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatalf("Cannot migrate database: %v", err)
+			}
+			var records []WorkerRecord
+			for i, online := range tc.online {
+				records = append(records, WorkerRecord{
+					MinerAddress:      testAddress,
+					Name:              "rig-01",
+					IsOnline:          online,
+					EffectiveHashrate: float64(10 * i),
+					ValidShares:       uint64(100 * (i + 1)),
+					CreatedAt:         start.Add(time.Duration(i) * 10 * time.Minute),
+				})
+			}
+			if trx := db.Create(&records); trx.Error != nil {
+				t.Fatalf("Cannot create records: %v", trx.Error)
+			}
+
+			if err := downsample(db, time.Time{}, start.Add(time.Hour), time.Hour); err != nil {
+				t.Fatalf("Cannot downsample: %v", err)
+			}
+			var downsampled []WorkerRecord
+			if trx := db.Find(&downsampled); trx.Error != nil {
+				t.Fatalf("Cannot fetch records: %v", trx.Error)
+			}
+			if len(downsampled) != 1 {
+				t.Fatalf("Expected one record, got %d", len(downsampled))
+			}
+			record := downsampled[0]
+			if record.IsOnline != tc.expectedOnline {
+				t.Errorf("Expected online to be %t, got %t", tc.expectedOnline, record.IsOnline)
+			}
+			if record.EffectiveHashrate != tc.expectedHashrate {
+				t.Errorf("Expected average hashrate %v, got %v", tc.expectedHashrate, record.EffectiveHashrate)
+			}
+			last := records[len(records)-1]
+			if record.ValidShares != last.ValidShares || !record.CreatedAt.Equal(last.CreatedAt) {
+				t.Errorf("Expected last shares %d at %s, got %d at %s", last.ValidShares, last.CreatedAt, record.ValidShares, record.CreatedAt)
+			}
+		})
+	}
+}
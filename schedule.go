@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxScheduleLookup to avoid infinite loop while searching for the next scheduled time
+const MaxScheduleLookup = 366 * 24 * time.Hour
+
+// scheduleAliases to store shortcuts to common schedules
+var scheduleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Schedule to store a cron-like schedule
+// Fields are minute, hour, day of month, month and day of week
+type Schedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	anyDay      bool
+	anyWeekday  bool
+}
+
+// ParseSchedule creates a Schedule from a cron expression (ex: "0 8 * * 1")
+func ParseSchedule(expression string) (*Schedule, error) {
+	if alias, ok := scheduleAliases[expression]; ok {
+		expression = alias
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Schedule %q must have 5 fields", expression)
+	}
+
+	var err error
+	schedule := &Schedule{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	if schedule.minutes, err = parseScheduleField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("Invalid minute: %v", err)
+	}
+	if schedule.hours, err = parseScheduleField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("Invalid hour: %v", err)
+	}
+	if schedule.daysOfMonth, err = parseScheduleField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("Invalid day of month: %v", err)
+	}
+	if schedule.months, err = parseScheduleField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("Invalid month: %v", err)
+	}
+	if schedule.daysOfWeek, err = parseScheduleField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("Invalid day of week: %v", err)
+	}
+	// Sunday can be either 0 or 7
+	if schedule.daysOfWeek[7] {
+		schedule.daysOfWeek[0] = true
+	}
+	return schedule, nil
+}
+
+// parseScheduleField parses a single field supporting "*", lists, ranges and steps (ex: "*/15", "5/15", "1-5", "0,30")
+func parseScheduleField(field string, min int, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		stepped := false
+		if i := strings.Index(part, "/"); i != -1 {
+			stepped = true
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			end = start
+			// Like cron, a single value with a step runs from the value to the maximum (ex: "5/15" is "5-59/15")
+			if stepped {
+				end = max
+			}
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			}
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// matches returns true when the time matches the schedule
+func (s *Schedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	// Like cron, when both days are restricted, either of them can match
+	if !s.anyDay && !s.anyWeekday {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// Next returns the first scheduled time strictly after the given time
+func (s *Schedule) Next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(MaxScheduleLookup)
+	for ; t.Before(limit); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("No scheduled time found before %s", limit)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		expression string
+		valid      bool
+	}{
+		{"0 8 * * *", true},
+		{"*/15 * * * *", true},
+		{"5/15 * * * *", true},
+		{"10-40/10 * * * *", true},
+		{"0,30 9-17 * * 1-5", true},
+		{"0 0 1,15 * *", true},
+		{"0 0 * * 7", true},
+		{"@hourly", true},
+		{"@daily", true},
+		{"@weekly", true},
+		{"@monthly", true},
+		{"", false},
+		{"0 8 * *", false},
+		{"0 8 * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"60/15 * * * *", false},
+		{"a * * * *", false},
+		{"@yearly", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expression, func(t *testing.T) {
+			_, err := ParseSchedule(tc.expression)
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid schedule, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid schedule")
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2021-09-05 is a Sunday
+	sunday := time.Date(2021, 9, 5, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		expected   time.Time
+	}{
+		{"every minute", "* * * * *", sunday, time.Date(2021, 9, 5, 10, 21, 0, 0, time.UTC)},
+		{"strictly after", "21 10 * * *", time.Date(2021, 9, 5, 10, 21, 0, 0, time.UTC), time.Date(2021, 9, 6, 10, 21, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", sunday, time.Date(2021, 9, 5, 10, 30, 0, 0, time.UTC)},
+		{"step from value", "5/15 * * * *", sunday, time.Date(2021, 9, 5, 10, 35, 0, 0, time.UTC)},
+		{"step from value wraps", "5/15 * * * *", time.Date(2021, 9, 5, 10, 50, 0, 0, time.UTC), time.Date(2021, 9, 5, 11, 5, 0, 0, time.UTC)},
+		{"step in range", "10-40/10 * * * *", time.Date(2021, 9, 5, 10, 45, 0, 0, time.UTC), time.Date(2021, 9, 5, 11, 10, 0, 0, time.UTC)},
+		{"list and range", "0,30 9-17 * * *", time.Date(2021, 9, 5, 17, 45, 0, 0, time.UTC), time.Date(2021, 9, 6, 9, 0, 0, 0, time.UTC)},
+		{"hourly", "@hourly", sunday, time.Date(2021, 9, 5, 11, 0, 0, 0, time.UTC)},
+		{"daily", "@daily", sunday, time.Date(2021, 9, 6, 0, 0, 0, 0, time.UTC)},
+		{"weekly", "@weekly", sunday, time.Date(2021, 9, 12, 0, 0, 0, 0, time.UTC)},
+		{"monthly", "@monthly", sunday, time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 0", "0 8 * * 0", time.Date(2021, 9, 6, 0, 0, 0, 0, time.UTC), time.Date(2021, 9, 12, 8, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 8 * * 7", time.Date(2021, 9, 6, 0, 0, 0, 0, time.UTC), time.Date(2021, 9, 12, 8, 0, 0, 0, time.UTC)},
+		{"weekdays", "0 8 * * 1-5", time.Date(2021, 9, 3, 9, 0, 0, 0, time.UTC), time.Date(2021, 9, 6, 8, 0, 0, 0, time.UTC)},
+		// Like cron, either day field matches when both are restricted
+		{"day of month before day of week", "0 0 7 * 5", sunday, time.Date(2021, 9, 7, 0, 0, 0, 0, time.UTC)},
+		{"day of week before day of month", "0 0 20 * 1", sunday, time.Date(2021, 9, 6, 0, 0, 0, 0, time.UTC)},
+		// Only the restricted day field applies when the other one is "*"
+		{"day of month only", "0 0 20 * *", sunday, time.Date(2021, 9, 20, 0, 0, 0, 0, time.UTC)},
+		{"day of week only", "0 0 * * 3", sunday, time.Date(2021, 9, 8, 0, 0, 0, 0, time.UTC)},
+		{"month", "0 0 1 1 *", sunday, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.expression)
+			if err != nil {
+				t.Fatalf("Cannot parse schedule: %v", err)
+			}
+			next, err := schedule.Next(tc.after)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !next.Equal(tc.expected) {
+				t.Errorf("Expected %s, got %s", tc.expected, next)
+			}
+		})
+	}
+}
+
+func TestScheduleNextLimit(t *testing.T) {
+	// The next 29th of February is beyond the lookup limit
+	schedule, err := ParseSchedule("0 0 29 2 *")
+	if err != nil {
+		t.Fatalf("Cannot parse schedule: %v", err)
+	}
+	if next, err := schedule.Next(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Expected no scheduled time, got %s", next)
+	}
+}
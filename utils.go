@@ -2,61 +2,88 @@ package main
 
 import (
 	"fmt"
+	"math/big"
+	"strings"
+	"time"
 )
 
-// WeisToETHDivider to divide Weis to ETH
-const WeisToETHDivider = 1000000000000000000
-
-// MojoToXCHDivider to divide Mojo to XCH
-const MojoToXCHDivider = 1000000000000
-
 // ConvertCurrency divides the smallest unit of the currency to the currency itself
 // Example: for "eth", convert from Weis to ETH
-func ConvertCurrency(coin string, value float64) (float64, error) {
-	switch coin {
-	case "etc":
-		return ConvertWeis(value), nil
-	case "eth":
-		return ConvertWeis(value), nil
-	case "xch":
-		return ConvertMojo(value), nil
-	default:
-		return 0, fmt.Errorf("Coin %s not supported", coin)
+func ConvertCurrency(coin string, value Amount) (*big.Float, error) {
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return nil, err
 	}
+	return registered.Convert(value), nil
 }
 
-// ConvertWeis converts the value from Weis to ETH
-func ConvertWeis(value float64) float64 {
-	return value / WeisToETHDivider
-}
-
-// ConvertMojo converts the value from Mojo to XCH
-func ConvertMojo(value float64) float64 {
-	return value / MojoToXCHDivider
+// ParseCurrency multiplies a value in the currency to its smallest unit
+// Example: for "eth", convert from ETH to Weis
+func ParseCurrency(coin string, value float64) (Amount, error) {
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return Amount{}, err
+	}
+	return registered.ParseUnits(value), nil
 }
 
 // FormatBlockURL returns the URL on the respective blockchain explorer given the coin and the block hash
 func FormatBlockURL(coin string, hash string) (string, error) {
-	switch coin {
-	case "etc":
-		return fmt.Sprintf("https://etcblockexplorer.com/block/%s", hash), nil
-	case "eth":
-		return fmt.Sprintf("https://etherscan.io/block/%s", hash), nil
-	case "xch":
-		return fmt.Sprintf("https://www.chiaexplorer.com/blockchain/block/%s", hash), nil
-	}
-	return "", fmt.Errorf("Coin %s not supported", coin)
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return "", err
+	}
+	return registered.FormatBlockURL(hash)
 }
 
 // FormatTransactionURL returns the URL on the respective blockchain explorer given the coin and the transaction hash
 func FormatTransactionURL(coin string, hash string) (string, error) {
-	switch coin {
-	case "etc":
-		return fmt.Sprintf("https://etcblockexplorer.com/address/%s", hash), nil
-	case "eth":
-		return fmt.Sprintf("https://etherscan.io/tx/%s", hash), nil
-	case "xch":
-		return fmt.Sprintf("https://www.chiaexplorer.com/blockchain/coin/%s", hash), nil
-	}
-	return "", fmt.Errorf("Coin %s not supported", coin)
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return "", err
+	}
+	return registered.FormatTransactionURL(hash)
+}
+
+// CoinName returns the display name of the coin or the coin itself when not supported
+func CoinName(coin string) string {
+	registered, err := Coins.Get(coin)
+	if err != nil {
+		return strings.ToUpper(coin)
+	}
+	return registered.DisplayName
+}
+
+// hashrateUnits to store units used to format hashrates
+var hashrateUnits = []string{"H/s", "KH/s", "MH/s", "GH/s", "TH/s", "PH/s", "EH/s"}
+
+// FormatHashrate returns a human readable hashrate (ex: "123.45 MH/s")
+func FormatHashrate(hashrate float64) string {
+	unit := 0
+	for hashrate >= 1000 && unit < len(hashrateUnits)-1 {
+		hashrate /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.2f %s", hashrate, hashrateUnits[unit])
+}
+
+// HumanizeDuration returns a short human readable duration (ex: "2h13m", "3d4h")
+func HumanizeDuration(duration time.Duration) string {
+	if duration < 0 {
+		duration = -duration
+	}
+	days := int(duration.Hours()) / 24
+	hours := int(duration.Hours()) % 24
+	minutes := int(duration.Minutes()) % 60
+	seconds := int(duration.Seconds()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
 }
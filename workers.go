@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// handleWorkers fetches workers and sends notifications when they go online or offline or when their hashrate drops
+func (a *Assistant) handleWorkers(configuredMiner MinerConfig, miner *Miner) error {
+	log.Debugf("Fetching workers for %s", miner)
+	workers, err := a.client.MinerWorkers(miner.Coin, miner.Address)
+	if err != nil {
+		return fmt.Errorf("Could not fetch workers: %v", err)
+	}
+	var names []string
+	for _, worker := range workers {
+		log.Debugf("Fetched %s", worker)
+		names = append(names, worker.Name)
+
+		var dbWorker Worker
+		trx := a.db.Where(Worker{MinerAddress: miner.Address, Name: worker.Name}).Attrs(Worker{MinerAddress: miner.Address, Name: worker.Name}).FirstOrCreate(&dbWorker)
+		if trx.Error != nil {
+			log.Warnf("Cannot fetch worker %s from database: %v", worker, trx.Error)
+			continue
+		}
+
+		// Skip first notification and workers we don't care about
+		notify := true
+		if dbWorker.LastSeen == nil || !watchedWorker(configuredMiner.Workers, worker.Name) {
+			notify = false
+		}
+
+		// Compare to the rolling average before recording the current hashrate
+		drop, err := a.hashrateDrop(configuredMiner.HashrateAlerts, worker)
+		if err != nil {
+			log.Warnf("Cannot compute hashrate drop of %s: %v", worker, err)
+		}
+		notifyDrop := drop != nil && !dbWorker.HashrateDropped
+		worker.HashrateDropped = drop != nil
+
+		// Shares are fetched from the worker stats only when they are alerted on
+		shareAlerts := configuredMiner.ShareAlerts
+		if shareAlerts.MaxStaleRatio > 0 || shareAlerts.MaxInvalidRatio > 0 {
+			stats, err := a.client.WorkerStats(miner.Coin, miner.Address, worker.Name)
+			if err != nil {
+				log.Warnf("Could not fetch stats of %s: %v", worker, err)
+			} else {
+				worker.ValidShares = stats.ValidShares
+				worker.StaleShares = stats.StaleShares
+				worker.InvalidShares = stats.InvalidShares
+			}
+		}
+
+		if trx = a.db.Create(NewWorkerRecord(worker)); trx.Error != nil {
+			log.Warnf("Cannot record worker %s: %v", worker, trx.Error)
+		}
+
+		// Count transitions after recording the current state
+		notifyOffline, flap, err := a.workerState(configuredMiner.OfflineAlerts, &dbWorker, worker)
+		if err != nil {
+			log.Warnf("Cannot compute state of %s: %v", worker, err)
+		}
+
+		// Compare to thresholds after recording the current shares
+		ratios, err := a.shareRatios(shareAlerts, worker)
+		if err != nil {
+			log.Warnf("Cannot compute share ratios of %s: %v", worker, err)
+		}
+		var notifyRatios []*ShareRatio
+		for _, ratio := range ratios {
+			switch ratio.Type {
+			case ShareTypeStale:
+				worker.StaleRatioExceeded = true
+				if !dbWorker.StaleRatioExceeded {
+					notifyRatios = append(notifyRatios, ratio)
+				}
+			case ShareTypeInvalid:
+				worker.InvalidRatioExceeded = true
+				if !dbWorker.InvalidRatioExceeded {
+					notifyRatios = append(notifyRatios, ratio)
+				}
+			}
+		}
+
+		worker.Model = dbWorker.Model
+		if trx = a.db.Save(worker); trx.Error != nil {
+			log.Warnf("Cannot update worker: %v", trx.Error)
+			continue
+		}
+
+		if !notify {
+			continue
+		}
+		if notifyOffline {
+			if err = a.notifier.NotifyOfflineWorker(*miner, *worker); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+			} else {
+				log.Infof("Offline worker notification sent for %s", worker)
+			}
+		}
+		if flap != nil {
+			if err = a.notifier.NotifyFlappingWorker(*miner, *worker, *flap); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+			} else {
+				log.Infof("Flapping worker notification sent for %s", worker)
+			}
+		}
+		if notifyDrop {
+			if err = a.notifier.NotifyHashrateDrop(*miner, *worker, *drop); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+			} else {
+				log.Infof("Hashrate drop notification sent for %s (%s)", worker, drop)
+			}
+		}
+		for _, ratio := range notifyRatios {
+			if err = a.notifier.NotifyShareRatio(*miner, *worker, *ratio); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+			} else {
+				log.Infof("Share ratio notification sent for %s (%s)", worker, ratio)
+			}
+		}
+	}
+	if err = a.handleMissingWorkers(configuredMiner.Workers, miner, names); err != nil {
+		return err
+	}
+	return a.handleExpectedWorkers(configuredMiner.Workers, miner)
+}
+
+// handleMissingWorkers sends a notification for each known worker that is not returned by the API anymore
+func (a *Assistant) handleMissingWorkers(configuredWorkers WorkersConfig, miner *Miner, names []string) error {
+	// Workers created before the missing column have a NULL value
+	var dbWorkers []Worker
+	query := a.db.Where("miner_address = ? AND missing IS NOT TRUE", miner.Address)
+	if len(names) > 0 {
+		query = query.Where("name NOT IN ?", names)
+	}
+	if trx := query.Find(&dbWorkers); trx.Error != nil {
+		return fmt.Errorf("Cannot fetch missing workers: %v", trx.Error)
+	}
+
+	for _, dbWorker := range dbWorkers {
+		log.Debugf("%s is missing", &dbWorker)
+		dbWorker.Missing = true
+		if trx := a.db.Save(&dbWorker); trx.Error != nil {
+			log.Warnf("Cannot update worker: %v", trx.Error)
+			continue
+		}
+		if !watchedWorker(configuredWorkers, dbWorker.Name) {
+			continue
+		}
+		if err := a.notifier.NotifyMissingWorker(*miner, dbWorker); err != nil {
+			log.Warnf("Cannot send notification: %v", err)
+			continue
+		}
+		log.Infof("Missing worker notification sent for %s", &dbWorker)
+	}
+	return nil
+}
+
+// handleExpectedWorkers sends a notification for each expected worker that has never been seen
+// Known workers going offline or missing are already notified by their own state
+func (a *Assistant) handleExpectedWorkers(configuredWorkers WorkersConfig, miner *Miner) error {
+	if len(configuredWorkers.Expected) == 0 {
+		return nil
+	}
+
+	var names []string
+	if trx := a.db.Model(&Worker{}).Where("miner_address = ?", miner.Address).Pluck("name", &names); trx.Error != nil {
+		return fmt.Errorf("Cannot fetch workers names: %v", trx.Error)
+	}
+
+	for _, pattern := range configuredWorkers.Expected {
+		var expectedWorker ExpectedWorker
+		trx := a.db.Where(ExpectedWorker{MinerAddress: miner.Address, Pattern: pattern}).Attrs(ExpectedWorker{MinerAddress: miner.Address, Pattern: pattern}).FirstOrCreate(&expectedWorker)
+		if trx.Error != nil {
+			log.Warnf("Cannot fetch expected worker %s from database: %v", pattern, trx.Error)
+			continue
+		}
+
+		found := false
+		for _, name := range names {
+			if matchWorker([]string{pattern}, name) {
+				found = true
+				break
+			}
+		}
+		if found == !expectedWorker.Missing {
+			continue
+		}
+
+		expectedWorker.Missing = !found
+		if trx = a.db.Save(&expectedWorker); trx.Error != nil {
+			log.Warnf("Cannot update expected worker: %v", trx.Error)
+			continue
+		}
+		if expectedWorker.Missing {
+			if err := a.notifier.NotifyMissingWorker(*miner, Worker{MinerAddress: miner.Address, Name: pattern}); err != nil {
+				log.Warnf("Cannot send notification: %v", err)
+				continue
+			}
+			log.Infof("Missing worker notification sent for %s", &expectedWorker)
+		}
+	}
+	return nil
+}
+
+// watchedWorker returns true when notifications should be sent for a worker given expected and ignored patterns
+func watchedWorker(configuredWorkers WorkersConfig, name string) bool {
+	if matchWorker(configuredWorkers.Ignored, name) {
+		return false
+	}
+	if len(configuredWorkers.Expected) > 0 {
+		return matchWorker(configuredWorkers.Expected, name)
+	}
+	return true
+}
+
+// matchWorker returns true when the worker name matches one of the glob patterns
+func matchWorker(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			log.Warnf("Invalid worker pattern %s: %v", pattern, err)
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// workerState follows online and offline transitions of a worker and returns whether a notification should be sent,
+// either for its current state once it is stable enough or because it is flapping
+func (a *Assistant) workerState(configuredAlerts OfflineAlertsConfig, dbWorker *Worker, worker *Worker) (notify bool, flap *WorkerFlap, err error) {
+	now := time.Now()
+	worker.StateChangedAt = dbWorker.StateChangedAt
+	worker.NotifiedOnline = dbWorker.NotifiedOnline
+	worker.Flapping = dbWorker.Flapping
+	worker.OfflineAt = dbWorker.OfflineAt
+
+	// State has never been followed, consider the last known state as notified
+	if worker.StateChangedAt == nil {
+		worker.StateChangedAt = &now
+		worker.NotifiedOnline = worker.IsOnline
+		if dbWorker.LastSeen != nil {
+			worker.NotifiedOnline = dbWorker.IsOnline
+		}
+	}
+	if worker.IsOnline != dbWorker.IsOnline {
+		worker.StateChangedAt = &now
+	}
+
+	// Remember when the worker went offline, the last share is more accurate than the current time
+	if !worker.IsOnline && (dbWorker.IsOnline || worker.OfflineAt == nil) {
+		offlineAt := now
+		if worker.LastSeen != nil && worker.LastSeen.Unix() > 0 && worker.LastSeen.Before(now) {
+			offlineAt = *worker.LastSeen
+		}
+		worker.OfflineAt = &offlineAt
+	}
+
+	if configuredAlerts.FlapThreshold > 0 {
+		window := FlapWindow
+		if configuredAlerts.FlapWindow > 0 {
+			window = configuredAlerts.FlapWindow
+		}
+		transitions, err := a.countTransitions(worker, now.Add(-window))
+		if err != nil {
+			return false, nil, err
+		}
+		if transitions >= configuredAlerts.FlapThreshold {
+			if worker.Flapping {
+				return false, nil, nil
+			}
+			worker.Flapping = true
+			return false, &WorkerFlap{Transitions: transitions, Window: window}, nil
+		}
+	}
+
+	if worker.IsOnline == worker.NotifiedOnline && !worker.Flapping {
+		return false, nil, nil
+	}
+
+	// Wait for the state to be stable
+	stableFor := now.Sub(*worker.StateChangedAt)
+	if worker.IsOnline && stableFor < configuredAlerts.MinOnline {
+		return false, nil, nil
+	}
+	if !worker.IsOnline && stableFor < configuredAlerts.GracePeriod {
+		return false, nil, nil
+	}
+
+	if worker.IsOnline && worker.OfflineAt != nil {
+		worker.Downtime = worker.StateChangedAt.Sub(*worker.OfflineAt)
+	}
+	worker.NotifiedOnline = worker.IsOnline
+	worker.Flapping = false
+	return true, nil, nil
+}
+
+// countTransitions returns the number of online and offline transitions of a worker since a given time
+func (a *Assistant) countTransitions(worker *Worker, since time.Time) (transitions int, err error) {
+	var records []WorkerRecord
+	trx := a.db.Select("is_online").Where("miner_address = ? AND name = ? AND created_at >= ?", worker.MinerAddress, worker.Name, since).Order("created_at").Find(&records)
+	if trx.Error != nil {
+		return 0, trx.Error
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].IsOnline != records[i-1].IsOnline {
+			transitions++
+		}
+	}
+	return transitions, nil
+}
+
+// hashrateDrop returns the largest hashrate drop of a worker compared to its rolling average when it exceeds the
+// configured limit
+func (a *Assistant) hashrateDrop(configuredAlerts HashrateAlertsConfig, worker *Worker) (drop *HashrateDrop, err error) {
+	if configuredAlerts.MaxDrop <= 0 || !worker.IsOnline {
+		return nil, nil
+	}
+
+	window := HashrateWindow
+	if configuredAlerts.Window > 0 {
+		window = configuredAlerts.Window
+	}
+
+	var average struct {
+		Effective float64
+		Reported  float64
+	}
+	trx := a.db.Model(&WorkerRecord{}).
+		Select("COALESCE(AVG(effective_hashrate), 0) AS effective, COALESCE(AVG(reported_hashrate), 0) AS reported").
+		Where("miner_address = ? AND name = ? AND created_at >= ?", worker.MinerAddress, worker.Name, time.Now().Add(-window)).
+		Scan(&average)
+	if trx.Error != nil {
+		return nil, trx.Error
+	}
+
+	candidates := []HashrateDrop{
+		{Type: HashrateTypeEffective, Current: worker.EffectiveHashrate, Average: average.Effective},
+		{Type: HashrateTypeReported, Current: worker.ReportedHashrate, Average: average.Reported},
+	}
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Average <= 0 {
+			continue
+		}
+		candidate.Drop = (candidate.Average - candidate.Current) / candidate.Average * 100
+		if candidate.Drop > configuredAlerts.MaxDrop && (drop == nil || candidate.Drop > drop.Drop) {
+			drop = candidate
+		}
+	}
+	return drop, nil
+}
+
+// shareRatios returns stale and invalid shares ratios of a worker over the configured window when they exceed their
+// thresholds
+func (a *Assistant) shareRatios(configuredAlerts ShareAlertsConfig, worker *Worker) (ratios []*ShareRatio, err error) {
+	if configuredAlerts.MaxStaleRatio <= 0 && configuredAlerts.MaxInvalidRatio <= 0 {
+		return nil, nil
+	}
+
+	window := SharesWindow
+	if configuredAlerts.Window > 0 {
+		window = configuredAlerts.Window
+	}
+
+	var records []WorkerRecord
+	trx := a.db.Where("miner_address = ? AND name = ? AND created_at >= ?", worker.MinerAddress, worker.Name, time.Now().Add(-window)).Find(&records)
+	if trx.Error != nil {
+		return nil, trx.Error
+	}
+
+	// Share counters cover a rolling window on the pool side, average the ratio of each record
+	var staleRatio, invalidRatio float64
+	count := 0
+	for _, record := range records {
+		total := float64(record.ValidShares + record.StaleShares + record.InvalidShares)
+		if total == 0 {
+			continue
+		}
+		staleRatio += float64(record.StaleShares) / total * 100
+		invalidRatio += float64(record.InvalidShares) / total * 100
+		count++
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	staleRatio /= float64(count)
+	invalidRatio /= float64(count)
+
+	if configuredAlerts.MaxStaleRatio > 0 && staleRatio > configuredAlerts.MaxStaleRatio {
+		ratios = append(ratios, &ShareRatio{Type: ShareTypeStale, Ratio: staleRatio, Threshold: configuredAlerts.MaxStaleRatio})
+	}
+	if configuredAlerts.MaxInvalidRatio > 0 && invalidRatio > configuredAlerts.MaxInvalidRatio {
+		ratios = append(ratios, &ShareRatio{Type: ShareTypeInvalid, Ratio: invalidRatio, Threshold: configuredAlerts.MaxInvalidRatio})
+	}
+	return ratios, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareRatios(t *testing.T) {
+	type shares struct{ valid, stale, invalid uint64 }
+	tests := []struct {
+		name     string
+		records  []shares
+		expected map[string]bool
+	}{
+		{
+			// Shares expiring from the rolling window compensate new ones
+			name: "steady state",
+			records: []shares{
+				{1000, 10, 1}, {998, 11, 1}, {1001, 10, 1}, {999, 12, 1}, {1000, 11, 1}, {997, 12, 1},
+			},
+			expected: map[string]bool{},
+		},
+		{
+			name:     "no shares",
+			records:  []shares{{0, 0, 0}, {0, 0, 0}},
+			expected: map[string]bool{},
+		},
+		{
+			name: "sustained stale shares",
+			records: []shares{
+				{900, 100, 1}, {900, 101, 1}, {899, 100, 1}, {901, 100, 1},
+			},
+			expected: map[string]bool{ShareTypeStale: true},
+		},
+		{
+			name: "invalid shares",
+			records: []shares{
+				{950, 10, 40}, {951, 10, 40},
+			},
+			expected: map[string]bool{ShareTypeInvalid: true},
+		},
+		{
+			// A single record does not exceed the threshold once averaged
+			name: "stale spike",
+			records: []shares{
+				{1000, 10, 0}, {1000, 10, 0}, {1000, 10, 0}, {1000, 200, 0},
+			},
+			expected: map[string]bool{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+			assistant := NewAssistant(NewConfig(), db, nil, nil)
+			worker := &Worker{MinerAddress: testAddress, Name: "rig-01"}
+			start := time.Now().Add(-time.Duration(len(tc.records)) * 5 * time.Minute)
+			for i, record := range tc.records {
+				workerRecord := &WorkerRecord{
+					MinerAddress:  worker.MinerAddress,
+					Name:          worker.Name,
+					ValidShares:   record.valid,
+					StaleShares:   record.stale,
+					InvalidShares: record.invalid,
+					CreatedAt:     start.Add(time.Duration(i) * 5 * time.Minute),
+				}
+				if trx := db.Create(workerRecord); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+			}
+
+			ratios, err := assistant.shareRatios(ShareAlertsConfig{MaxStaleRatio: 5, MaxInvalidRatio: 2}, worker)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(ratios) != len(tc.expected) {
+				t.Fatalf("Expected %d ratio(s), got %d", len(tc.expected), len(ratios))
+			}
+			for _, ratio := range ratios {
+				if !tc.expected[ratio.Type] {
+					t.Errorf("Unexpected %s ratio of %.2f%%", ratio.Type, ratio.Ratio)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleMissingWorkersAfterUpgrade(t *testing.T) {
+	db := newBaselineDatabase(t,
+		"INSERT INTO workers (created_at, updated_at, miner_address, name, is_online, last_seen) VALUES ('2021-11-01 00:00:00', '2021-11-01 00:00:00', '"+testAddress+"', 'rig-01', 1, '2021-11-01 00:00:00')",
+		"INSERT INTO workers (created_at, updated_at, miner_address, name, is_online, last_seen) VALUES ('2021-11-01 00:00:00', '2021-11-01 00:00:00', '"+testAddress+"', 'rig-02', 1, '2021-11-01 00:00:00')",
+	)
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &TelegramNotifier{configurations: &NotificationsConfig{Digest: DigestConfig{Enable: true}}}
+	assistant := NewAssistant(NewConfig(), db, nil, notifier)
+	miner := &Miner{Address: testAddress, Coin: "eth"}
+	if err := assistant.handleMissingWorkers(WorkersConfig{}, miner, []string{"rig-01"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Attachment.Worker.Name != "rig-02" {
+		t.Fatalf("Expected rig-02 to be notified as missing, got %+v", notifier.events)
+	}
+
+	// Missing workers are notified once
+	if err := assistant.handleMissingWorkers(WorkersConfig{}, miner, []string{"rig-01"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(notifier.events) != 1 {
+		t.Errorf("Expected a single notification, got %d", len(notifier.events))
+	}
+}
+
+func TestWorkerState(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	tests := []struct {
+		name             string
+		alerts           OfflineAlertsConfig
+		dbWorker         Worker
+		online           bool
+		history          []bool
+		expectedNotify   bool
+		expectedFlap     bool
+		expectedDowntime time.Duration
+	}{
+		{
+			name:     "new worker",
+			dbWorker: Worker{},
+			online:   false,
+		},
+		{
+			// State has never been followed but the worker was known online
+			name:           "known worker going offline",
+			dbWorker:       Worker{IsOnline: true, LastSeen: ago(time.Hour)},
+			online:         false,
+			expectedNotify: true,
+		},
+		{
+			name:     "offline within grace period",
+			alerts:   OfflineAlertsConfig{GracePeriod: 10 * time.Minute},
+			dbWorker: Worker{IsOnline: true, NotifiedOnline: true, StateChangedAt: ago(time.Hour)},
+			online:   false,
+		},
+		{
+			name:           "offline after grace period",
+			alerts:         OfflineAlertsConfig{GracePeriod: 10 * time.Minute},
+			dbWorker:       Worker{IsOnline: false, NotifiedOnline: true, StateChangedAt: ago(20 * time.Minute)},
+			online:         false,
+			expectedNotify: true,
+		},
+		{
+			name:     "offline already notified",
+			dbWorker: Worker{IsOnline: false, NotifiedOnline: false, StateChangedAt: ago(time.Hour)},
+			online:   false,
+		},
+		{
+			name:     "online before minimum duration",
+			alerts:   OfflineAlertsConfig{MinOnline: 5 * time.Minute},
+			dbWorker: Worker{IsOnline: true, NotifiedOnline: false, StateChangedAt: ago(time.Minute), OfflineAt: ago(time.Hour)},
+			online:   true,
+		},
+		{
+			name:             "back online",
+			alerts:           OfflineAlertsConfig{MinOnline: 5 * time.Minute},
+			dbWorker:         Worker{IsOnline: true, NotifiedOnline: false, StateChangedAt: ago(10 * time.Minute), OfflineAt: ago(time.Hour)},
+			online:           true,
+			expectedNotify:   true,
+			expectedDowntime: 50 * time.Minute,
+		},
+		{
+			name:         "flapping",
+			alerts:       OfflineAlertsConfig{FlapThreshold: 3},
+			dbWorker:     Worker{IsOnline: true, NotifiedOnline: true, StateChangedAt: ago(time.Minute)},
+			online:       false,
+			history:      []bool{true, false, true, false, true, false},
+			expectedFlap: true,
+		},
+		{
+			name:     "still flapping",
+			alerts:   OfflineAlertsConfig{FlapThreshold: 3},
+			dbWorker: Worker{IsOnline: true, NotifiedOnline: true, Flapping: true, StateChangedAt: ago(time.Minute)},
+			online:   false,
+			history:  []bool{true, false, true, false, true, false},
+		},
+		{
+			// The stable state is notified once flapping stops
+			name:           "stable after flapping",
+			alerts:         OfflineAlertsConfig{FlapThreshold: 3},
+			dbWorker:       Worker{IsOnline: true, NotifiedOnline: true, Flapping: true, StateChangedAt: ago(30 * time.Minute)},
+			online:         true,
+			history:        []bool{true, true, true},
+			expectedNotify: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+			for i, online := range tc.history {
+				record := &WorkerRecord{MinerAddress: testAddress, Name: "rig-01", IsOnline: online, CreatedAt: now.Add(time.Duration(i-len(tc.history)) * time.Minute)}
+				if trx := db.Create(record); trx.Error != nil {
+					t.Fatal(trx.Error)
+				}
+			}
+			assistant := NewAssistant(NewConfig(), db, nil, nil)
+			dbWorker := tc.dbWorker
+			worker := &Worker{MinerAddress: testAddress, Name: "rig-01", IsOnline: tc.online}
+
+			notify, flap, err := assistant.workerState(tc.alerts, &dbWorker, worker)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if notify != tc.expectedNotify {
+				t.Errorf("Expected notify to be %t, got %t", tc.expectedNotify, notify)
+			}
+			if (flap != nil) != tc.expectedFlap {
+				t.Errorf("Expected flap to be %t, got %+v", tc.expectedFlap, flap)
+			}
+			if notify && worker.NotifiedOnline != tc.online {
+				t.Errorf("Expected notified state to be %t", tc.online)
+			}
+			if diff := worker.Downtime - tc.expectedDowntime; diff < -time.Second || diff > time.Second {
+				t.Errorf("Expected downtime of %s, got %s", tc.expectedDowntime, worker.Downtime)
+			}
+		})
+	}
+}
+
+func TestCountTransitions(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		history  []bool
+		expected int
+	}{
+		{"no history", nil, 0},
+		{"always online", []bool{true, true, true}, 0},
+		{"went offline", []bool{true, true, false}, 1},
+		{"flapping", []bool{true, false, true, false}, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if err := MigrateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+			records := []WorkerRecord{
+				// Records before the window and of other workers are ignored
+				{MinerAddress: testAddress, Name: "rig-01", IsOnline: false, CreatedAt: now.Add(-2 * time.Hour)},
+				{MinerAddress: testAddress, Name: "rig-02", IsOnline: false, CreatedAt: now.Add(-time.Minute)},
+			}
+			for i, online := range tc.history {
+				records = append(records, WorkerRecord{MinerAddress: testAddress, Name: "rig-01", IsOnline: online, CreatedAt: now.Add(time.Duration(i-len(tc.history)) * time.Minute)})
+			}
+			if trx := db.Create(&records); trx.Error != nil {
+				t.Fatal(trx.Error)
+			}
+
+			assistant := NewAssistant(NewConfig(), db, nil, nil)
+			worker := &Worker{MinerAddress: testAddress, Name: "rig-01"}
+			transitions, err := assistant.countTransitions(worker, now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if transitions != tc.expected {
+				t.Errorf("Expected %d transition(s), got %d", tc.expected, transitions)
+			}
+		})
+	}
+}